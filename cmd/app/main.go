@@ -2,9 +2,9 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/Detsl735/avito-test/internal/config"
-	"github.com/Detsl735/avito-test/internal/domain"
 	transport "github.com/Detsl735/avito-test/internal/http"
 	"github.com/Detsl735/avito-test/internal/repository"
 	"github.com/Detsl735/avito-test/internal/service"
@@ -14,14 +14,17 @@ import (
 )
 
 func main() {
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
 
 	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{})
 	if err != nil {
 		log.Fatalf("failed to connect db: %v", err)
 	}
 
-	if err := db.AutoMigrate(&domain.Team{}, &domain.User{}, &domain.PullRequest{}, &domain.Reviewer{}); err != nil {
+	if err := repository.AutoMigrate(db); err != nil {
 		log.Fatalf("failed to migrate: %v", err)
 	}
 
@@ -29,12 +32,23 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	prRepo := repository.NewPRRepository(db)
 	statsRepo := repository.NewStatsRepository(db)
+	unavailRepo := repository.NewUnavailabilityRepository(db)
 
-	teamSvc := service.NewTeamService(db, teamRepo, userRepo)
-	userSvc := service.NewUserService(db, userRepo)
-	prSvc := service.NewPRService(db, prRepo, userRepo)
+	selectors, err := service.NewSelectorSet(cfg.ReviewerStrategy, prRepo.CountOpenReviews)
+	if err != nil {
+		log.Fatalf("failed to init reviewer selectors: %v", err)
+	}
+	seed := cfg.ReviewerSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	log.Printf("reviewer selection seed: %d", seed)
+	prSvc := service.NewPRService(db, prRepo, userRepo, teamRepo, unavailRepo, selectors, service.NewSeedSource(seed))
+	teamSvc := service.NewTeamService(db, teamRepo, userRepo, unavailRepo, prSvc)
+	userSvc := service.NewUserService(db, userRepo, teamRepo, prRepo, prSvc)
+	availabilitySvc := service.NewAvailabilityService(db, unavailRepo, userRepo)
 
-	router := transport.NewRouter(teamSvc, userSvc, prSvc, statsRepo)
+	router := transport.NewRouter(teamSvc, userSvc, prSvc, availabilitySvc, statsRepo)
 
 	if err := router.Run(":" + cfg.AppPort); err != nil {
 		log.Fatalf("failed to run server: %v", err)
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 )
 
 type Config struct {
@@ -14,9 +15,18 @@ type Config struct {
 	DBSSLMode  string
 
 	AppPort string
+
+	ReviewerStrategy string
+	// ReviewerSeed seeds reviewer selection. Zero picks a random seed at start.
+	ReviewerSeed int64
 }
 
-func Load() *Config {
+func Load() (*Config, error) {
+	seed, err := getEnvInt64("REVIEWER_SEED", 0)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
 		DBHost:     getEnv("DB_HOST", "db"),
 		DBPort:     getEnv("DB_PORT", "5432"),
@@ -25,8 +35,11 @@ func Load() *Config {
 		DBName:     getEnv("DB_NAME", "pr_service"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 		AppPort:    getEnv("APP_PORT", "8080"),
+
+		ReviewerStrategy: getEnv("REVIEWER_STRATEGY", "least_loaded"),
+		ReviewerSeed:     seed,
 	}
-	return cfg
+	return cfg, nil
 }
 
 func (c *Config) DSN() string {
@@ -42,3 +55,15 @@ func getEnv(key, def string) string {
 	}
 	return def
 }
+
+func getEnvInt64(key string, def int64) (int64, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: must be an integer", key, raw)
+	}
+	return v, nil
+}
@@ -0,0 +1,104 @@
+package domain
+
+import "time"
+
+// Assignment operations recorded in AssignmentDecision.
+const (
+	AssignmentCreate   = "create"
+	AssignmentOpen     = "open"
+	AssignmentReassign = "reassign"
+)
+
+// ExclusionReason tells why a considered user was not eligible for review.
+type ExclusionReason string
+
+const (
+	ExcludedAuthor      ExclusionReason = "author"
+	ExcludedInactive    ExclusionReason = "inactive"
+	ExcludedUnavailable ExclusionReason = "unavailable"
+	ExcludedAssigned    ExclusionReason = "already_assigned"
+	ExcludedAtCapacity  ExclusionReason = "at_capacity"
+)
+
+// How a chosen reviewer got into the pull request.
+const (
+	ViaCodeOwners   = "code_owners"
+	ViaRequiredRole = "required_role"
+	ViaTeam         = "team"
+	ViaFallback     = "fallback"
+	ViaParent       = "parent_team"
+	// ViaRequested marks a replacement explicitly requested by the caller.
+	ViaRequested = "requested"
+)
+
+// AssignmentDecision is the record of one reviewer assignment round: every
+// user that was considered, why they were excluded or how they were chosen.
+// Seed alone replays only the random strategy; the others also depend on
+// the reviewer load in the database and the in-process rotation state.
+type AssignmentDecision struct {
+	ID             int64               `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	PullRequestID  string              `gorm:"column:pull_request_id;not null;index" json:"pull_request_id"`
+	Operation      string              `gorm:"column:operation;not null" json:"operation"`
+	AuthorID       string              `gorm:"column:author_id;not null" json:"author_id"`
+	ReplacedUserID string              `gorm:"column:replaced_user_id;not null;default:''" json:"replaced_user_id,omitempty"`
+	Seed           int64               `gorm:"column:seed;not null" json:"seed"`
+	Candidates     []CandidateDecision `gorm:"column:candidates;type:text;serializer:json" json:"candidates"`
+	CreatedAt      time.Time           `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (AssignmentDecision) TableName() string {
+	return "assignment_decisions"
+}
+
+// CandidateDecision is the outcome for one considered user. Users that were
+// neither excluded nor chosen were eligible but lost to the strategy.
+type CandidateDecision struct {
+	UserID       string          `json:"user_id"`
+	TeamName     string          `json:"team_name"`
+	Excluded     ExclusionReason `json:"excluded,omitempty"`
+	Chosen       bool            `json:"chosen"`
+	Via          string          `json:"via,omitempty"`
+	Strategy     string          `json:"strategy,omitempty"`
+	MatchedRule  string          `json:"matched_rule,omitempty"`
+	FallbackTeam string          `json:"fallback_team,omitempty"`
+}
+
+// Note records that u was considered with the given outcome. Only the first
+// outcome for a user is kept: later passes see chosen users as assigned.
+func (d *AssignmentDecision) Note(u User, reason ExclusionReason) {
+	if d.find(u.UserID) != nil {
+		return
+	}
+	d.Candidates = append(d.Candidates, CandidateDecision{UserID: u.UserID, TeamName: u.TeamName, Excluded: reason})
+}
+
+// Picked records how userID was picked. The pick may still be dropped,
+// only Choose marks the final reviewers.
+func (d *AssignmentDecision) Picked(userID, via string) {
+	if c := d.find(userID); c != nil {
+		c.Via = via
+	}
+}
+
+// Choose marks reviewers as the outcome of the round.
+func (d *AssignmentDecision) Choose(reviewers []Reviewer) {
+	for _, rv := range reviewers {
+		c := d.find(rv.UserID)
+		if c == nil {
+			continue
+		}
+		c.Chosen = true
+		c.Strategy = rv.Strategy
+		c.MatchedRule = rv.MatchedRule
+		c.FallbackTeam = rv.FallbackTeam
+	}
+}
+
+func (d *AssignmentDecision) find(userID string) *CandidateDecision {
+	for i := range d.Candidates {
+		if d.Candidates[i].UserID == userID {
+			return &d.Candidates[i]
+		}
+	}
+	return nil
+}
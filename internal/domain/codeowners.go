@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"path"
+	"strings"
+)
+
+// CodeOwnerRule is one line of a team's CODEOWNERS-like file: files matching
+// Pattern are owned by Owners. When several rules of a team match a file,
+// the one with the highest Position wins, as in CODEOWNERS.
+type CodeOwnerRule struct {
+	ID       int64    `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	TeamName string   `gorm:"column:team_name;not null;index" json:"-"`
+	Position int      `gorm:"column:position;not null" json:"-"`
+	Pattern  string   `gorm:"column:pattern;not null" json:"pattern"`
+	Owners   []string `gorm:"column:owners;type:text;serializer:json" json:"owners"`
+}
+
+func (CodeOwnerRule) TableName() string {
+	return "code_owner_rules"
+}
+
+// Matches reports whether filePath is covered by the rule pattern. Patterns
+// follow gitignore rules used by CODEOWNERS: a leading "/" anchors the
+// pattern to the repository root, a pattern without inner slashes matches at
+// any depth, a trailing "/" matches directories only, "*" matches within one
+// path segment and "**" matches any number of segments. A pattern naming a
+// directory covers every file below it, while "docs/*" covers only direct
+// children of docs.
+func (r CodeOwnerRule) Matches(filePath string) bool {
+	pattern := strings.TrimSpace(r.Pattern)
+	if pattern == "" {
+		return false
+	}
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+	anchored := strings.HasPrefix(r.Pattern, "/") || strings.Contains(pattern, "/")
+
+	segs := strings.Split(strings.Trim(path.Clean("/"+filePath), "/"), "/")
+	pat := strings.Split(pattern, "/")
+	coversDir := !strings.ContainsAny(pat[len(pat)-1], "*?[")
+
+	starts := len(segs)
+	if anchored {
+		starts = 1
+	}
+	for start := 0; start < starts; start++ {
+		rest := len(segs) - start
+		for _, k := range matchedLengths(pat, segs[start:]) {
+			if k == rest && !dirOnly {
+				return true
+			}
+			if k > 0 && k < rest && coversDir {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchedLengths returns every number of leading segments of segs that
+// pattern segments pat can match.
+func matchedLengths(pat, segs []string) []int {
+	if len(pat) == 0 {
+		return []int{0}
+	}
+
+	if pat[0] == "**" {
+		var res []int
+		for i := 0; i <= len(segs); i++ {
+			for _, k := range matchedLengths(pat[1:], segs[i:]) {
+				res = append(res, i+k)
+			}
+		}
+		return res
+	}
+
+	if len(segs) == 0 {
+		return nil
+	}
+	if ok, _ := path.Match(pat[0], segs[0]); !ok {
+		return nil
+	}
+
+	var res []int
+	for _, k := range matchedLengths(pat[1:], segs[1:]) {
+		res = append(res, k+1)
+	}
+	return res
+}
+
+// OwningRule returns the rule that owns filePath: the last matching one.
+func OwningRule(rules []CodeOwnerRule, filePath string) (CodeOwnerRule, bool) {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Matches(filePath) {
+			return rules[i], true
+		}
+	}
+	return CodeOwnerRule{}, false
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeOwnerRule_Matches(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*", "main.go", true},
+		{"*.go", "internal/service/pr_service.go", true},
+		{"*.go", "README.md", false},
+		{"/docs/", "docs/api/openapi.yml", true},
+		{"/docs/", "src/docs/readme.md", false},
+		{"docs/", "src/docs/readme.md", true},
+		{"docs/", "src/docs", false},
+		{"docs", "src/docs/readme.md", true},
+		{"docs/*", "docs/index.md", true},
+		{"docs/*", "docs/api/index.md", false},
+		{"internal/**/repository", "internal/a/b/repository/user.go", true},
+		{"/cmd/app/main.go", "cmd/app/main.go", true},
+		{"/cmd/app/main.go", "cmd/app/main_test.go", false},
+		{"", "main.go", false},
+	}
+	for _, tc := range cases {
+		rule := CodeOwnerRule{Pattern: tc.pattern}
+		require.Equal(t, tc.want, rule.Matches(tc.path), "%q on %q", tc.pattern, tc.path)
+	}
+}
@@ -3,11 +3,26 @@ package domain
 import "errors"
 
 var (
-	ErrTeamExists = errors.New("team already exists")
+	ErrTeamExists      = errors.New("team already exists")
+	ErrUnknownStrategy = errors.New("unknown reviewer strategy")
+	ErrInvalidSettings = errors.New("invalid team settings")
+	ErrInvalidPeriod   = errors.New("period must end after it starts")
+	ErrInvalidFilter   = errors.New("invalid filter")
+	ErrTeamNotEmpty    = errors.New("team still has members or reviewers on open pull requests")
 
 	ErrPRExists    = errors.New("pr already exists")
 	ErrPRMerged    = errors.New("pr already merged")
+	ErrPRNotOpen   = errors.New("pr is not open")
 	ErrNotAssigned = errors.New("user is not assigned as reviewer")
 	ErrNoCandidate = errors.New("no candidate for reviewer")
 	ErrNotFound    = errors.New("not found")
+
+	ErrInvalidReviewState = errors.New("invalid review state")
+	ErrMergeBlocked       = errors.New("merge requirements not met")
+	ErrInvalidTransition  = errors.New("invalid pr status transition")
+	ErrRoleRuleViolated   = errors.New("not enough reviewers with the required role")
+	ErrForbidden          = errors.New("only the author or an admin may do this")
+	ErrAlreadyAssigned    = errors.New("user is already assigned as reviewer")
+	ErrTooManyReviewers   = errors.New("pr already has the maximum number of reviewers")
+	ErrIneligibleReviewer = errors.New("user cannot review this pr")
 )
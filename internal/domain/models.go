@@ -1,70 +1,410 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type User struct {
 	UserID   string `gorm:"column:user_id;primaryKey"`
 	Username string `gorm:"column:username;not null"`
-	TeamName string `gorm:"column:team_name;not null;index"`
-	IsActive bool   `gorm:"column:is_active;not null;default:true"`
+	// TeamName and Teams are not columns, they are filled from TeamMembership
+	// rows. On reads TeamName is the primary team and Teams all teams with the
+	// primary one first. On writes Teams is ignored and TeamName is the team to
+	// join, which becomes primary only for a user without one.
+	TeamName       string   `gorm:"-"`
+	Teams          []string `gorm:"-"`
+	IsActive       bool     `gorm:"column:is_active;not null;default:true"`
+	MaxOpenReviews *int     `gorm:"column:max_open_reviews"`
+	// Tags are skills of the user (e.g. "go", "sql") matched against PR labels.
+	Tags []string `gorm:"column:tags;type:text;serializer:json"`
+	// Role is the seniority of the user, e.g. "senior". Empty means none.
+	Role string `gorm:"column:role;not null;default:''"`
+	// IsAdmin allows editing reviewers of any pull request and force merges.
+	// It is not settable through the team endpoints.
+	IsAdmin bool `gorm:"column:is_admin;not null;default:false"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
 
+// InTeam reports whether the user is a member of teamName.
+func (u User) InTeam(teamName string) bool {
+	for _, t := range u.Teams {
+		if t == teamName {
+			return true
+		}
+	}
+	return u.TeamName == teamName && teamName != ""
+}
+
+// EffectiveMaxOpenReviews returns the user's own limit of concurrent OPEN
+// reviews or teamDefault when the user has none. Zero means unlimited.
+func (u User) EffectiveMaxOpenReviews(teamDefault int) int {
+	if u.MaxOpenReviews != nil {
+		return *u.MaxOpenReviews
+	}
+	return teamDefault
+}
+
+// TagOverlap returns how many of labels are among the user's tags.
+// The comparison is case-insensitive.
+func (u User) TagOverlap(labels []string) int {
+	tags := make(map[string]bool, len(u.Tags))
+	for _, t := range u.Tags {
+		tags[strings.ToLower(t)] = true
+	}
+
+	n := 0
+	for _, l := range labels {
+		if tags[strings.ToLower(l)] {
+			n++
+		}
+	}
+	return n
+}
+
 type Team struct {
-	TeamName string `gorm:"column:team_name;primaryKey"`
+	TeamName       string `gorm:"column:team_name;primaryKey" json:"team_name"`
+	ReviewStrategy string `gorm:"column:review_strategy;not null;default:''" json:"review_strategy"`
+	// DefaultMaxOpenReviews limits concurrent OPEN reviews for members without
+	// a personal limit. Zero means unlimited.
+	DefaultMaxOpenReviews int `gorm:"column:default_max_open_reviews;not null;default:0" json:"default_max_open_reviews"`
+	MinReviewers          int `gorm:"column:min_reviewers;not null;default:2" json:"min_reviewers"`
+	MaxReviewers          int `gorm:"column:max_reviewers;not null;default:2" json:"max_reviewers"`
+	// RequiredApprovals is how many APPROVED reviews a pull request needs
+	// before it can be merged without force.
+	RequiredApprovals int `gorm:"column:required_approvals;not null;default:0" json:"required_approvals"`
+	// RequiredRole and RequiredRoleCount form the rule "at least
+	// RequiredRoleCount reviewers of every pull request have RequiredRole".
+	// A zero count disables the rule.
+	RequiredRole      string `gorm:"column:required_role;not null;default:''" json:"required_role"`
+	RequiredRoleCount int    `gorm:"column:required_role_count;not null;default:0" json:"required_role_count"`
+	// ParentTeam makes the team a sub-team of another one, e.g.
+	// "payments/backend" of "payments". Empty for top-level teams.
+	ParentTeam string `gorm:"column:parent_team;not null;default:'';index" json:"parent_team"`
+	// ClimbToParent lets reviewer selection go up to the closest ancestor
+	// with eligible candidates when the team itself has none.
+	ClimbToParent bool `gorm:"column:climb_to_parent;not null;default:false" json:"climb_to_parent"`
 }
 
 func (Team) TableName() string {
 	return "teams"
 }
 
+const (
+	DefaultMinReviewers = 2
+	DefaultMaxReviewers = 2
+)
+
+// TeamSettings is a partial update of team settings, nil fields are left as is.
+type TeamSettings struct {
+	ReviewStrategy        *string
+	DefaultMaxOpenReviews *int
+	MinReviewers          *int
+	MaxReviewers          *int
+	RequiredApprovals     *int
+	RequiredRole          *string
+	RequiredRoleCount     *int
+	ParentTeam            *string
+	ClimbToParent         *bool
+}
+
+// TeamFilter selects teams for listing by name prefix. Limit is the page
+// size, Cursor is NextCursor of the previous page.
+type TeamFilter struct {
+	NamePrefix string
+	Limit      int
+	Cursor     string
+}
+
+// TeamSummary is a listed team with member counts and OpenReviews, the number
+// of reviews of OPEN pull requests its members are assigned to.
+type TeamSummary struct {
+	TeamName      string `json:"team_name"`
+	ParentTeam    string `json:"parent_team,omitempty"`
+	Members       int64  `json:"members"`
+	ActiveMembers int64  `json:"active_members"`
+	OpenReviews   int64  `json:"open_reviews"`
+}
+
+// TeamPage is one page of listed teams. NextCursor is empty on the last page.
+type TeamPage struct {
+	Teams      []TeamSummary
+	NextCursor string
+}
+
+// TeamNode is a team with its sub-teams.
+type TeamNode struct {
+	TeamName string     `json:"team_name"`
+	Children []TeamNode `json:"children"`
+}
+
+// TeamDeleteOptions controls what happens to members of a deleted team.
+// Without Cascade a team with members or with reviewers borrowed from it on
+// OPEN pull requests is not deleted, and MoveTo must be empty. With Cascade
+// members are moved to MoveTo. When MoveTo is empty members of other teams
+// just leave and the rest is deactivated with their OPEN reviews reassigned.
+// Either way reviews borrowed from the team are reassigned within the teams
+// of the pull request authors.
+type TeamDeleteOptions struct {
+	Cascade bool
+	MoveTo  string
+}
+
 type TeamMember struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID   string   `json:"user_id"`
+	Username string   `json:"username"`
+	IsActive bool     `json:"is_active"`
+	Tags     []string `json:"tags,omitempty"`
+	Role     string   `json:"role,omitempty"`
+	// IsAvailable is the effective availability: active and not inside an
+	// unavailability period. It is only filled in responses.
+	IsAvailable *bool `json:"is_available,omitempty"`
+}
+
+// Unavailability is a period when a user must not get new reviews,
+// e.g. a vacation. The period covers [StartsAt, EndsAt).
+type Unavailability struct {
+	ID       int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID   string    `gorm:"column:user_id;not null;index" json:"user_id"`
+	StartsAt time.Time `gorm:"column:starts_at;not null" json:"starts_at"`
+	EndsAt   time.Time `gorm:"column:ends_at;not null" json:"ends_at"`
+	Reason   string    `gorm:"column:reason;not null;default:''" json:"reason"`
+}
+
+func (Unavailability) TableName() string {
+	return "unavailabilities"
 }
 
 type PRStatus string
 
 const (
+	PRStatusDraft  PRStatus = "DRAFT"
 	PRStatusOpen   PRStatus = "OPEN"
 	PRStatusMerged PRStatus = "MERGED"
+	PRStatusClosed PRStatus = "CLOSED"
 )
 
+var prTransitions = map[PRStatus][]PRStatus{
+	PRStatusDraft:  {PRStatusOpen, PRStatusClosed},
+	PRStatusOpen:   {PRStatusMerged, PRStatusClosed},
+	PRStatusClosed: {PRStatusOpen},
+}
+
+// CanTransitionTo reports whether a pull request in status s may be moved to next.
+func (s PRStatus) CanTransitionTo(next PRStatus) bool {
+	for _, st := range prTransitions[s] {
+		if st == next {
+			return true
+		}
+	}
+	return false
+}
+
 type PullRequest struct {
 	PullRequestID   string     `gorm:"column:pull_request_id;primaryKey"`
 	PullRequestName string     `gorm:"column:pull_request_name;not null"`
 	AuthorID        string     `gorm:"column:author_id;not null;index"`
-	Status          PRStatus   `gorm:"column:status;type:varchar(16);not null"`
-	CreatedAt       time.Time  `gorm:"column:created_at;not null"`
-	MergedAt        *time.Time `gorm:"column:merged_at"`
+	Status          PRStatus   `gorm:"column:status;type:varchar(16);not null;index:idx_pr_status_created,priority:1"`
+	CreatedAt       time.Time  `gorm:"column:created_at;not null;index;index:idx_pr_status_created,priority:2"`
+	MergedAt        *time.Time `gorm:"column:merged_at;index"`
+	ClosedAt        *time.Time `gorm:"column:closed_at"`
+	// FilePaths are paths changed by the pull request, used to find code owners.
+	FilePaths []string `gorm:"column:file_paths;type:text;serializer:json"`
+	// Labels describe the expertise the pull request needs, see User.Tags.
+	Labels []string `gorm:"column:labels;type:text;serializer:json"`
+	// ForceMerged marks pull requests merged by an admin bypassing approval rules.
+	ForceMerged bool   `gorm:"column:force_merged;not null;default:false"`
+	MergedBy    string `gorm:"column:merged_by;not null;default:''"`
 }
 
 func (PullRequest) TableName() string {
 	return "pull_requests"
 }
 
+type ReviewState string
+
+const (
+	ReviewStatePending          ReviewState = "PENDING"
+	ReviewStateApproved         ReviewState = "APPROVED"
+	ReviewStateChangesRequested ReviewState = "CHANGES_REQUESTED"
+	ReviewStateDismissed        ReviewState = "DISMISSED"
+)
+
+// IsVerdict reports whether a reviewer may submit s as the result of a review.
+func (s ReviewState) IsVerdict() bool {
+	switch s {
+	case ReviewStateApproved, ReviewStateChangesRequested, ReviewStateDismissed:
+		return true
+	}
+	return false
+}
+
 type Reviewer struct {
 	ID            int64  `gorm:"column:id;primaryKey;autoIncrement"`
 	PullRequestID string `gorm:"column:pull_request_id;not null;index"`
 	UserID        string `gorm:"column:user_id;not null;index"`
+	// FallbackTeam is the team the reviewer was borrowed from when the
+	// author's team could not provide enough reviewers. Empty otherwise.
+	FallbackTeam string `gorm:"column:fallback_team;not null;default:''"`
+	// MatchedRule is the code owners pattern that made this user a reviewer.
+	MatchedRule string `gorm:"column:matched_rule;not null;default:''"`
+	// Strategy and Seed record how the reviewer was selected, so that the
+	// choice can be replayed.
+	Strategy   string      `gorm:"column:strategy;not null;default:''"`
+	Seed       int64       `gorm:"column:seed;not null;default:0"`
+	State      ReviewState `gorm:"column:state;type:varchar(32);not null;default:'PENDING'"`
+	AssignedAt time.Time   `gorm:"column:assigned_at;not null;default:CURRENT_TIMESTAMP"`
+	ReviewedAt *time.Time  `gorm:"column:reviewed_at"`
 }
 
 func (Reviewer) TableName() string {
 	return "reviewers"
 }
 
+// TeamMembership puts a user into a team. A user may be in several teams,
+// exactly one of them is primary: it is the team of the user's pull requests.
+type TeamMembership struct {
+	UserID    string `gorm:"column:user_id;primaryKey"`
+	TeamName  string `gorm:"column:team_name;primaryKey;index"`
+	IsPrimary bool   `gorm:"column:is_primary;not null"`
+}
+
+func (TeamMembership) TableName() string {
+	return "team_memberships"
+}
+
+// TeamFallback points a team to another team its reviewers may be borrowed
+// from. Fallbacks of one team are tried in ascending Position.
+type TeamFallback struct {
+	TeamName         string `gorm:"column:team_name;primaryKey"`
+	FallbackTeamName string `gorm:"column:fallback_team_name;primaryKey"`
+	Position         int    `gorm:"column:position;not null"`
+}
+
+func (TeamFallback) TableName() string {
+	return "team_fallbacks"
+}
+
 type PullRequestFull struct {
 	PullRequest
 	AssignedReviewers []string
+	Reviewers         []Reviewer
+	// MissingReviewers is how many reviewers were lacking to reach the team
+	// minimum at assignment time. It is not persisted.
+	MissingReviewers int
+	// Author is filled only when the pull request is fetched on its own.
+	Author *User
 }
 
 type PullRequestShort struct {
-	PullRequestID   string   `json:"pull_request_id"`
-	PullRequestName string   `json:"pull_request_name"`
-	AuthorID        string   `json:"author_id"`
-	Status          PRStatus `json:"status"`
+	PullRequestID   string      `json:"pull_request_id"`
+	PullRequestName string      `json:"pull_request_name"`
+	AuthorID        string      `json:"author_id"`
+	Status          PRStatus    `json:"status"`
+	ReviewState     ReviewState `json:"review_state,omitempty"`
+	ReviewedAt      *time.Time  `json:"reviewed_at,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	MergedAt        *time.Time  `json:"merged_at,omitempty"`
+}
+
+type ReviewCapacity struct {
+	UserID         string `json:"user_id"`
+	MaxOpenReviews *int   `json:"max_open_reviews"`
+	TeamDefault    int    `json:"team_default_max_open_reviews"`
+	Effective      int    `json:"effective_max_open_reviews"`
+	OpenReviews    int64  `json:"open_reviews"`
+}
+
+// ReviewMove describes one OPEN review moved away from a user. ToUserID is
+// empty when no replacement could be found, Reason then explains why.
+type ReviewMove struct {
+	PullRequestID string `json:"pull_request_id"`
+	FromUserID    string `json:"from_user_id"`
+	ToUserID      string `json:"to_user_id,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+type ReassignReport struct {
+	Moved         []ReviewMove `json:"moved"`
+	NotReassigned []ReviewMove `json:"not_reassigned"`
+}
+
+// MembershipReport lists users that left a team and what happened to their
+// OPEN reviews there.
+type MembershipReport struct {
+	UserIDs []string `json:"user_ids"`
+	ReassignReport
+}
+
+// TeamDeletionReport describes a deleted team and where its members went.
+type TeamDeletionReport struct {
+	TeamName     string   `json:"team_name"`
+	MovedMembers []string `json:"moved_members"`
+	MovedTo      string   `json:"moved_to,omitempty"`
+	// LeftMembers only left the team as they are members of other teams.
+	LeftMembers []string `json:"left_members"`
+	Deactivated []string `json:"deactivated"`
+	ReassignReport
+}
+
+type DeactivationReport struct {
+	Deactivated []string `json:"deactivated"`
+	ReassignReport
+}
+
+// CreatePROptions carries optional data of a new pull request.
+type CreatePROptions struct {
+	// Draft creates the pull request in DRAFT status without reviewers.
+	Draft     bool
+	FilePaths []string
+	Labels    []string
+}
+
+// Sort fields of PRFilter.
+const (
+	PRSortCreatedAt = "created_at"
+	PRSortName      = "pull_request_name"
+)
+
+// PRFilter selects pull requests for listing. Zero fields do not filter.
+// Time ranges include From and exclude To.
+type PRFilter struct {
+	Status       PRStatus
+	AuthorID     string
+	ReviewerID   string
+	TeamName     string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	MergedFrom   *time.Time
+	MergedTo     *time.Time
+	NameContains string
+
+	Sort string
+	Desc bool
+	// Limit is the page size, Cursor is NextCursor of the previous page.
+	Limit  int
+	Cursor string
+}
+
+// PRPage is one page of listed pull requests. NextCursor is empty on the last page.
+type PRPage struct {
+	PullRequests []PullRequestFull
+	NextCursor   string
+}
+
+// ReviewFilter selects pull requests a user reviews. Zero Limit returns all.
+type ReviewFilter struct {
+	Status PRStatus
+	Limit  int
+	Cursor string
+}
+
+// ReviewPage is one page of reviewed pull requests ordered by creation time.
+// NextCursor is empty on the last page.
+type ReviewPage struct {
+	PullRequests []PullRequestShort
+	NextCursor   string
 }
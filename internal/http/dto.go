@@ -1,6 +1,10 @@
 package http
 
-import "github.com/Detsl735/avito-test/internal/domain"
+import (
+	"time"
+
+	"github.com/Detsl735/avito-test/internal/domain"
+)
 
 type ErrorResponse struct {
 	Error struct {
@@ -21,60 +25,291 @@ type TeamAddResponse struct {
 	} `json:"team"`
 }
 
+type SetReviewStrategyRequest struct {
+	TeamName       string `json:"team_name" binding:"required"`
+	ReviewStrategy string `json:"review_strategy"`
+}
+
+type TeamSettingsRequest struct {
+	TeamName              string  `json:"team_name" binding:"required"`
+	ReviewStrategy        *string `json:"review_strategy"`
+	DefaultMaxOpenReviews *int    `json:"default_max_open_reviews"`
+	MinReviewers          *int    `json:"min_reviewers"`
+	MaxReviewers          *int    `json:"max_reviewers"`
+	RequiredApprovals     *int    `json:"required_approvals"`
+	RequiredRole          *string `json:"required_role"`
+	RequiredRoleCount     *int    `json:"required_role_count"`
+	ParentTeam            *string `json:"parent_team"`
+	ClimbToParent         *bool   `json:"climb_to_parent"`
+}
+
+type TeamFallbacksRequest struct {
+	TeamName      string   `json:"team_name" binding:"required"`
+	FallbackTeams []string `json:"fallback_teams" binding:"required"`
+}
+
+type TeamFallbacksResponse struct {
+	TeamName      string   `json:"team_name"`
+	FallbackTeams []string `json:"fallback_teams"`
+}
+
+type TeamCodeOwnersRequest struct {
+	TeamName string                 `json:"team_name" binding:"required"`
+	Rules    []domain.CodeOwnerRule `json:"rules" binding:"required"`
+}
+
+type TeamCodeOwnersResponse struct {
+	TeamName string                 `json:"team_name"`
+	Rules    []domain.CodeOwnerRule `json:"rules"`
+}
+
+type TeamMembersAddRequest struct {
+	TeamName string              `json:"team_name" binding:"required"`
+	Members  []domain.TeamMember `json:"members" binding:"required,min=1"`
+}
+
+type TeamMembersRemoveRequest struct {
+	TeamName string   `json:"team_name" binding:"required"`
+	UserIDs  []string `json:"user_ids" binding:"required,min=1"`
+	// ReassignReviews moves OPEN reviews of removed users to remaining members.
+	ReassignReviews bool `json:"reassign_reviews"`
+}
+
+type TeamMoveMemberRequest struct {
+	UserID   string `json:"user_id" binding:"required"`
+	TeamName string `json:"team_name" binding:"required"`
+	// ReassignReviews moves the user's OPEN reviews to members of the old team.
+	ReassignReviews bool `json:"reassign_reviews"`
+}
+
+type TeamRenameRequest struct {
+	TeamName    string `json:"team_name" binding:"required"`
+	NewTeamName string `json:"new_team_name" binding:"required"`
+}
+
+type TeamDeleteRequest struct {
+	TeamName string `json:"team_name" binding:"required"`
+	// Cascade allows deleting a team with members: they are moved to MoveTo,
+	// or deactivated with their OPEN reviews reassigned when it is empty.
+	Cascade bool   `json:"cascade"`
+	MoveTo  string `json:"move_to"`
+}
+
+type TeamListRequest struct {
+	Prefix string `form:"prefix"`
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+}
+
+type TeamListResponse struct {
+	Teams      []domain.TeamSummary `json:"teams"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+type TeamTreeResponse struct {
+	Teams []domain.TeamNode `json:"teams"`
+}
+
+type TeamResponse struct {
+	Team domain.Team `json:"team"`
+}
+
 type SetIsActiveRequest struct {
 	UserID   string `json:"user_id" binding:"required"`
 	IsActive bool   `json:"is_active"`
+	// ReassignReviews moves OPEN reviews of a deactivated user to teammates.
+	ReassignReviews bool `json:"reassign_reviews"`
+}
+
+type DeactivateUsersRequest struct {
+	UserIDs         []string `json:"user_ids" binding:"required,min=1"`
+	ReassignReviews bool     `json:"reassign_reviews"`
+}
+
+type SetMaxOpenReviewsRequest struct {
+	UserID         string `json:"user_id" binding:"required"`
+	MaxOpenReviews *int   `json:"max_open_reviews" binding:"omitempty,min=0"`
+}
+
+type SetTeamReviewCapacityRequest struct {
+	TeamName              string `json:"team_name" binding:"required"`
+	DefaultMaxOpenReviews int    `json:"default_max_open_reviews" binding:"min=0"`
+}
+
+type ReviewCapacityResponse struct {
+	Capacity domain.ReviewCapacity `json:"capacity"`
+}
+
+type UserDTO struct {
+	UserID         string   `json:"user_id"`
+	Username       string   `json:"username"`
+	TeamName       string   `json:"team_name"`
+	Teams          []string `json:"teams,omitempty"`
+	IsActive       bool     `json:"is_active"`
+	MaxOpenReviews *int     `json:"max_open_reviews"`
+	Tags           []string `json:"tags,omitempty"`
+	Role           string   `json:"role,omitempty"`
+	IsAdmin        bool     `json:"is_admin"`
 }
 
 type UserResponse struct {
-	User domain.User `json:"user"`
+	User         UserDTO                    `json:"user"`
+	Reassignment *domain.DeactivationReport `json:"reassignment,omitempty"`
+}
+
+type UnavailabilityAddRequest struct {
+	UserID   string    `json:"user_id" binding:"required"`
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason"`
+}
+
+type UnavailabilityUpdateRequest struct {
+	ID       int64     `json:"id" binding:"required"`
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason"`
+}
+
+type UnavailabilityDeleteRequest struct {
+	ID int64 `json:"id" binding:"required"`
+}
+
+type UnavailabilityResponse struct {
+	Period domain.Unavailability `json:"period"`
+}
+
+type UnavailabilityListResponse struct {
+	UserID  string                  `json:"user_id"`
+	Periods []domain.Unavailability `json:"periods"`
 }
 
 type PullRequestCreateRequest struct {
 	PullRequestID   string `json:"pull_request_id" binding:"required"`
 	PullRequestName string `json:"pull_request_name" binding:"required"`
 	AuthorID        string `json:"author_id" binding:"required"`
+	// Draft creates the PR in DRAFT status without reviewers.
+	Draft bool `json:"draft"`
+	// FilePaths are the changed files, used to prefer their code owners.
+	FilePaths []string `json:"file_paths"`
+	// Labels steer selection towards reviewers with matching tags.
+	Labels []string `json:"labels"`
+}
+
+type ReviewerDTO struct {
+	UserID       string  `json:"user_id"`
+	FallbackTeam string  `json:"fallback_team,omitempty"`
+	MatchedRule  string  `json:"matched_rule,omitempty"`
+	Strategy     string  `json:"strategy,omitempty"`
+	Seed         int64   `json:"seed,omitempty"`
+	State        string  `json:"state"`
+	AssignedAt   string  `json:"assignedAt,omitempty"`
+	ReviewedAt   *string `json:"reviewedAt,omitempty"`
+}
+
+type PullRequestDTO struct {
+	PullRequestID   string        `json:"pull_request_id"`
+	PullRequestName string        `json:"pull_request_name"`
+	AuthorID        string        `json:"author_id"`
+	AuthorName      string        `json:"author_name,omitempty"`
+	AuthorTeam      string        `json:"author_team,omitempty"`
+	Status          string        `json:"status"`
+	Labels          []string      `json:"labels,omitempty"`
+	Assigned        []string      `json:"assigned_reviewers"`
+	Reviewers       []ReviewerDTO `json:"reviewers"`
+	CreatedAt       string        `json:"createdAt,omitempty"`
+	MergedAt        *string       `json:"mergedAt,omitempty"`
+	ClosedAt        *string       `json:"closedAt,omitempty"`
+	ForceMerged     bool          `json:"force_merged,omitempty"`
+	MergedBy        string        `json:"merged_by,omitempty"`
+	// MissingReviewers is set when fewer than the team minimum could be assigned.
+	MissingReviewers int `json:"missing_reviewers,omitempty"`
 }
 
 type PullRequestResponse struct {
-	PR struct {
-		PullRequestID   string   `json:"pull_request_id"`
-		PullRequestName string   `json:"pull_request_name"`
-		AuthorID        string   `json:"author_id"`
-		Status          string   `json:"status"`
-		Assigned        []string `json:"assigned_reviewers"`
-		CreatedAt       string   `json:"createdAt,omitempty"`
-		MergedAt        *string  `json:"mergedAt,omitempty"`
-	} `json:"pr"`
+	PR PullRequestDTO `json:"pr"`
 }
 
 type PullRequestMergeRequest struct {
 	PullRequestID string `json:"pull_request_id" binding:"required"`
+	// Force bypasses approval rules, MergedBy is required with it and must be
+	// an admin.
+	Force    bool   `json:"force"`
+	MergedBy string `json:"merged_by" binding:"required_if=Force true"`
+}
+
+type PullRequestStatusRequest struct {
+	PullRequestID string `json:"pull_request_id" binding:"required"`
+}
+
+type PullRequestReviewRequest struct {
+	PullRequestID string `json:"pull_request_id" binding:"required"`
+	UserID        string `json:"user_id" binding:"required"`
+	State         string `json:"state" binding:"required"`
 }
 
 type PullRequestReassignRequest struct {
 	PullRequestID string `json:"pull_request_id" binding:"required"`
 	OldUserID     string `json:"old_user_id" binding:"required"`
+	// NewUserID, when set, is used as the replacement instead of a picked one.
+	NewUserID string `json:"new_user_id"`
+}
+
+// PullRequestReviewerRequest adds or removes UserID on behalf of ActorID,
+// who must be the PR author or an admin.
+type PullRequestReviewerRequest struct {
+	PullRequestID string `json:"pull_request_id" binding:"required"`
+	ActorID       string `json:"actor_id" binding:"required"`
+	UserID        string `json:"user_id" binding:"required"`
 }
 
 type PullRequestReassignResponse struct {
-	PR struct {
-		PullRequestID   string   `json:"pull_request_id"`
-		PullRequestName string   `json:"pull_request_name"`
-		AuthorID        string   `json:"author_id"`
-		Status          string   `json:"status"`
-		Assigned        []string `json:"assigned_reviewers"`
-		CreatedAt       string   `json:"createdAt,omitempty"`
-		MergedAt        *string  `json:"mergedAt,omitempty"`
-	} `json:"pr"`
-	ReplacedBy string `json:"replaced_by"`
+	PR         PullRequestDTO `json:"pr"`
+	ReplacedBy string         `json:"replaced_by"`
+}
+
+// GetReviewRequest holds query parameters of /users/getReview. Without limit
+// all matching pull requests are returned.
+type GetReviewRequest struct {
+	UserID string `form:"user_id" binding:"required"`
+	Status string `form:"status"`
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
 }
 
 type GetReviewResponse struct {
 	UserID       string                    `json:"user_id"`
 	PullRequests []domain.PullRequestShort `json:"pull_requests"`
+	NextCursor   string                    `json:"next_cursor,omitempty"`
 }
 
 type StatsResponse struct {
 	Assignments map[string]int64 `json:"assignments"`
 }
+
+type AssignmentExplainResponse struct {
+	Decision domain.AssignmentDecision `json:"decision"`
+}
+
+// PullRequestListRequest holds query parameters of /pullRequest/list.
+// Time bounds are RFC 3339, ranges include the lower and exclude the upper bound.
+type PullRequestListRequest struct {
+	Status      string     `form:"status"`
+	AuthorID    string     `form:"author_id"`
+	ReviewerID  string     `form:"reviewer_id"`
+	TeamName    string     `form:"team_name"`
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	MergedFrom  *time.Time `form:"merged_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	MergedTo    *time.Time `form:"merged_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Name        string     `form:"name"`
+	Sort        string     `form:"sort"`
+	Order       string     `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit       int        `form:"limit"`
+	Cursor      string     `form:"cursor"`
+}
+
+type PullRequestListResponse struct {
+	PullRequests []PullRequestDTO `json:"pull_requests"`
+	NextCursor   string           `json:"next_cursor,omitempty"`
+}
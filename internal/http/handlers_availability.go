@@ -0,0 +1,109 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Detsl735/avito-test/internal/domain"
+	"github.com/Detsl735/avito-test/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AvailabilityHandler struct {
+	availabilityService service.AvailabilityService
+}
+
+func NewAvailabilityHandler(availabilitySvc service.AvailabilityService) *AvailabilityHandler {
+	return &AvailabilityHandler{availabilityService: availabilitySvc}
+}
+
+func (h *AvailabilityHandler) Register(r *gin.RouterGroup) {
+	r.POST("/users/unavailability/add", h.Add)
+	r.GET("/users/unavailability/list", h.List)
+	r.POST("/users/unavailability/update", h.Update)
+	r.POST("/users/unavailability/delete", h.Delete)
+}
+
+func (h *AvailabilityHandler) Add(c *gin.Context) {
+	var req UnavailabilityAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	period, err := h.availabilityService.AddPeriod(c.Request.Context(), domain.Unavailability{
+		UserID:   req.UserID,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, UnavailabilityResponse{Period: *period})
+}
+
+func (h *AvailabilityHandler) List(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, errorBadRequest("user_id is required"))
+		return
+	}
+
+	periods, err := h.availabilityService.ListPeriods(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, UnavailabilityListResponse{UserID: userID, Periods: periods})
+}
+
+func (h *AvailabilityHandler) Update(c *gin.Context) {
+	var req UnavailabilityUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	period, err := h.availabilityService.UpdatePeriod(c.Request.Context(), domain.Unavailability{
+		ID:       req.ID,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, UnavailabilityResponse{Period: *period})
+}
+
+func (h *AvailabilityHandler) Delete(c *gin.Context) {
+	var req UnavailabilityDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	if err := h.availabilityService.DeletePeriod(c.Request.Context(), req.ID); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": req.ID})
+}
+
+func (h *AvailabilityHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPeriod):
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_PERIOD", "ends_at must be after starts_at"))
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "user or period not found"))
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+	}
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"time"
@@ -23,6 +24,15 @@ func (h *PRHandler) Register(r *gin.RouterGroup) {
 	r.POST("/pullRequest/create", h.CreatePR)
 	r.POST("/pullRequest/merge", h.MergePR)
 	r.POST("/pullRequest/reassign", h.Reassign)
+	r.POST("/pullRequest/reviewers/add", h.AddReviewer)
+	r.POST("/pullRequest/reviewers/remove", h.RemoveReviewer)
+	r.POST("/pullRequest/review", h.Review)
+	r.POST("/pullRequest/ready", h.MarkReady)
+	r.POST("/pullRequest/close", h.ClosePR)
+	r.POST("/pullRequest/reopen", h.ReopenPR)
+	r.GET("/pullRequest/assignment-explain", h.ExplainAssignment)
+	r.GET("/pullRequest/list", h.List)
+	r.GET("/pullRequest/get", h.Get)
 }
 
 func (h *PRHandler) CreatePR(c *gin.Context) {
@@ -32,7 +42,8 @@ func (h *PRHandler) CreatePR(c *gin.Context) {
 		return
 	}
 
-	full, err := h.prService.CreatePR(c.Request.Context(), req.PullRequestID, req.PullRequestName, req.AuthorID)
+	opts := domain.CreatePROptions{Draft: req.Draft, FilePaths: req.FilePaths, Labels: req.Labels}
+	full, err := h.prService.CreatePRWithOptions(c.Request.Context(), req.PullRequestID, req.PullRequestName, req.AuthorID, opts)
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrPRExists):
@@ -41,6 +52,9 @@ func (h *PRHandler) CreatePR(c *gin.Context) {
 		case errors.Is(err, domain.ErrNotFound):
 			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "author or team not found"))
 			return
+		case errors.Is(err, domain.ErrRoleRuleViolated):
+			c.JSON(http.StatusConflict, errorResponse("ROLE_RULE_VIOLATED", "not enough available reviewers with the role required by the team"))
+			return
 		default:
 			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
 			return
@@ -57,14 +71,33 @@ func (h *PRHandler) MergePR(c *gin.Context) {
 		return
 	}
 
-	full, err := h.prService.MergePR(c.Request.Context(), req.PullRequestID)
+	var (
+		full *domain.PullRequestFull
+		err  error
+	)
+	if req.Force {
+		full, err = h.prService.ForceMergePR(c.Request.Context(), req.PullRequestID, req.MergedBy)
+	} else {
+		full, err = h.prService.MergePR(c.Request.Context(), req.PullRequestID)
+	}
 	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "pr not found"))
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "pr or merged_by user not found"))
+			return
+		case errors.Is(err, domain.ErrForbidden):
+			c.JSON(http.StatusForbidden, errorResponse("FORBIDDEN", "only an admin may force a merge"))
+			return
+		case errors.Is(err, domain.ErrMergeBlocked):
+			c.JSON(http.StatusConflict, errorResponse("MERGE_BLOCKED", err.Error()))
+			return
+		case errors.Is(err, domain.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, errorResponse("INVALID_TRANSITION", "only OPEN PR can be merged"))
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
-		return
 	}
 
 	c.JSON(http.StatusOK, prToResponse(full))
@@ -77,7 +110,17 @@ func (h *PRHandler) Reassign(c *gin.Context) {
 		return
 	}
 
-	full, replacedBy, err := h.prService.ReassignReviewer(c.Request.Context(), req.PullRequestID, req.OldUserID)
+	var (
+		full       *domain.PullRequestFull
+		replacedBy string
+		err        error
+	)
+	if req.NewUserID != "" {
+		full, err = h.prService.ReassignReviewerTo(c.Request.Context(), req.PullRequestID, req.OldUserID, req.NewUserID)
+		replacedBy = req.NewUserID
+	} else {
+		full, replacedBy, err = h.prService.ReassignReviewer(c.Request.Context(), req.PullRequestID, req.OldUserID)
+	}
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrNotFound):
@@ -86,12 +129,21 @@ func (h *PRHandler) Reassign(c *gin.Context) {
 		case errors.Is(err, domain.ErrPRMerged):
 			c.JSON(http.StatusConflict, errorResponse("PR_MERGED", "cannot reassign on merged PR"))
 			return
+		case errors.Is(err, domain.ErrPRNotOpen):
+			c.JSON(http.StatusConflict, errorResponse("PR_NOT_OPEN", "cannot reassign on draft or closed PR"))
+			return
 		case errors.Is(err, domain.ErrNotAssigned):
 			c.JSON(http.StatusConflict, errorResponse("NOT_ASSIGNED", "reviewer is not assigned to this PR"))
 			return
 		case errors.Is(err, domain.ErrNoCandidate):
 			c.JSON(http.StatusConflict, errorResponse("NO_CANDIDATE", "no active replacement candidate in team"))
 			return
+		case errors.Is(err, domain.ErrRoleRuleViolated):
+			c.JSON(http.StatusConflict, errorResponse("ROLE_RULE_VIOLATED", "no replacement with the role required by the team"))
+			return
+		case errors.Is(err, domain.ErrIneligibleReviewer):
+			c.JSON(http.StatusConflict, errorResponse("INELIGIBLE_REVIEWER", "new_user_id is not an eligible replacement"))
+			return
 		default:
 			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
 			return
@@ -106,19 +158,273 @@ func (h *PRHandler) Reassign(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+func (h *PRHandler) Review(c *gin.Context) {
+	var req PullRequestReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	full, err := h.prService.SubmitReview(c.Request.Context(), req.PullRequestID, req.UserID, domain.ReviewState(req.State))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidReviewState):
+			c.JSON(http.StatusBadRequest, errorResponse("INVALID_STATE", "state must be one of APPROVED, CHANGES_REQUESTED, DISMISSED"))
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "pr not found"))
+			return
+		case errors.Is(err, domain.ErrPRMerged):
+			c.JSON(http.StatusConflict, errorResponse("PR_MERGED", "cannot review merged PR"))
+			return
+		case errors.Is(err, domain.ErrPRNotOpen):
+			c.JSON(http.StatusConflict, errorResponse("PR_NOT_OPEN", "cannot review draft or closed PR"))
+			return
+		case errors.Is(err, domain.ErrNotAssigned):
+			c.JSON(http.StatusConflict, errorResponse("NOT_ASSIGNED", "reviewer is not assigned to this PR"))
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, prToResponse(full))
+}
+
+func (h *PRHandler) MarkReady(c *gin.Context) {
+	h.transition(c, h.prService.MarkReady, "only DRAFT PR can be marked ready")
+}
+
+func (h *PRHandler) ClosePR(c *gin.Context) {
+	h.transition(c, h.prService.ClosePR, "only DRAFT or OPEN PR can be closed")
+}
+
+func (h *PRHandler) ReopenPR(c *gin.Context) {
+	h.transition(c, h.prService.ReopenPR, "only CLOSED PR can be reopened")
+}
+
+// transition handles lifecycle endpoints that take a pull request id and
+// move it to another status with fn.
+func (h *PRHandler) transition(
+	c *gin.Context,
+	fn func(ctx context.Context, id string) (*domain.PullRequestFull, error),
+	invalidMsg string,
+) {
+	var req PullRequestStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	full, err := fn(c.Request.Context(), req.PullRequestID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "pr not found"))
+			return
+		case errors.Is(err, domain.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, errorResponse("INVALID_TRANSITION", invalidMsg))
+			return
+		case errors.Is(err, domain.ErrRoleRuleViolated):
+			c.JSON(http.StatusConflict, errorResponse("ROLE_RULE_VIOLATED", "not enough available reviewers with the role required by the team"))
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, prToResponse(full))
+}
+
+func (h *PRHandler) AddReviewer(c *gin.Context) {
+	h.editReviewers(c, h.prService.AddReviewer)
+}
+
+func (h *PRHandler) RemoveReviewer(c *gin.Context) {
+	h.editReviewers(c, h.prService.RemoveReviewer)
+}
+
+// editReviewers handles endpoints that explicitly add or remove one reviewer.
+func (h *PRHandler) editReviewers(
+	c *gin.Context,
+	fn func(ctx context.Context, prID, actorID, userID string) (*domain.PullRequestFull, error),
+) {
+	var req PullRequestReviewerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	full, err := fn(c.Request.Context(), req.PullRequestID, req.ActorID, req.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "pr or user not found"))
+			return
+		case errors.Is(err, domain.ErrForbidden):
+			c.JSON(http.StatusForbidden, errorResponse("FORBIDDEN", "only the PR author or an admin may edit reviewers"))
+			return
+		case errors.Is(err, domain.ErrPRMerged):
+			c.JSON(http.StatusConflict, errorResponse("PR_MERGED", "cannot edit reviewers of merged PR"))
+			return
+		case errors.Is(err, domain.ErrPRNotOpen):
+			c.JSON(http.StatusConflict, errorResponse("PR_NOT_OPEN", "cannot edit reviewers of draft or closed PR"))
+			return
+		case errors.Is(err, domain.ErrAlreadyAssigned):
+			c.JSON(http.StatusConflict, errorResponse("ALREADY_ASSIGNED", "user is already a reviewer of this PR"))
+			return
+		case errors.Is(err, domain.ErrNotAssigned):
+			c.JSON(http.StatusConflict, errorResponse("NOT_ASSIGNED", "reviewer is not assigned to this PR"))
+			return
+		case errors.Is(err, domain.ErrIneligibleReviewer):
+			c.JSON(http.StatusConflict, errorResponse("INELIGIBLE_REVIEWER", "user must be active, available, within review capacity, not the author and in the author's team or its fallback teams"))
+			return
+		case errors.Is(err, domain.ErrTooManyReviewers):
+			c.JSON(http.StatusConflict, errorResponse("TOO_MANY_REVIEWERS", "PR already has max_reviewers reviewers"))
+			return
+		case errors.Is(err, domain.ErrRoleRuleViolated):
+			c.JSON(http.StatusConflict, errorResponse("ROLE_RULE_VIOLATED", "reviewer is required by the team role rule, reassign instead"))
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, prToResponse(full))
+}
+
+func (h *PRHandler) ExplainAssignment(c *gin.Context) {
+	prID := c.Query("pull_request_id")
+	if prID == "" {
+		c.JSON(http.StatusBadRequest, errorBadRequest("pull_request_id is required"))
+		return
+	}
+
+	decision, err := h.prService.ExplainAssignment(c.Request.Context(), prID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "pr not found or has no reviewer assignment yet"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, AssignmentExplainResponse{Decision: *decision})
+}
+
+func (h *PRHandler) Get(c *gin.Context) {
+	prID := c.Query("pull_request_id")
+	if prID == "" {
+		c.JSON(http.StatusBadRequest, errorBadRequest("pull_request_id is required"))
+		return
+	}
+
+	full, err := h.prService.GetPR(c.Request.Context(), prID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "pr not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, prToResponse(full))
+}
+
+func (h *PRHandler) List(c *gin.Context) {
+	var req PullRequestListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	page, err := h.prService.ListPRs(c.Request.Context(), domain.PRFilter{
+		Status:       domain.PRStatus(req.Status),
+		AuthorID:     req.AuthorID,
+		ReviewerID:   req.ReviewerID,
+		TeamName:     req.TeamName,
+		CreatedFrom:  req.CreatedFrom,
+		CreatedTo:    req.CreatedTo,
+		MergedFrom:   req.MergedFrom,
+		MergedTo:     req.MergedTo,
+		NameContains: req.Name,
+		Sort:         req.Sort,
+		Desc:         req.Order == "desc",
+		Limit:        req.Limit,
+		Cursor:       req.Cursor,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, errorResponse("INVALID_FILTER", "invalid status, sort, limit (1..100) or cursor"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	resp := PullRequestListResponse{
+		PullRequests: make([]PullRequestDTO, 0, len(page.PullRequests)),
+		NextCursor:   page.NextCursor,
+	}
+	for i := range page.PullRequests {
+		resp.PullRequests = append(resp.PullRequests, prToDTO(&page.PullRequests[i]))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 func prToResponse(full *domain.PullRequestFull) PullRequestResponse {
-	resp := PullRequestResponse{}
-	resp.PR.PullRequestID = full.PullRequestID
-	resp.PR.PullRequestName = full.PullRequestName
-	resp.PR.AuthorID = full.AuthorID
-	resp.PR.Status = string(full.Status)
-	resp.PR.Assigned = full.AssignedReviewers
+	return PullRequestResponse{PR: prToDTO(full)}
+}
+
+func prToDTO(full *domain.PullRequestFull) PullRequestDTO {
+	var res PullRequestDTO
+	res.PullRequestID = full.PullRequestID
+	res.PullRequestName = full.PullRequestName
+	res.AuthorID = full.AuthorID
+	if full.Author != nil {
+		res.AuthorName = full.Author.Username
+		res.AuthorTeam = full.Author.TeamName
+	}
+	res.Status = string(full.Status)
+	res.Labels = full.Labels
+	res.Assigned = full.AssignedReviewers
+	res.Reviewers = make([]ReviewerDTO, 0, len(full.Reviewers))
+	for _, rv := range full.Reviewers {
+		dto := ReviewerDTO{
+			UserID:       rv.UserID,
+			FallbackTeam: rv.FallbackTeam,
+			MatchedRule:  rv.MatchedRule,
+			Strategy:     rv.Strategy,
+			Seed:         rv.Seed,
+			State:        string(rv.State),
+		}
+		if !rv.AssignedAt.IsZero() {
+			dto.AssignedAt = rv.AssignedAt.UTC().Format(time.RFC3339)
+		}
+		if rv.ReviewedAt != nil {
+			t := rv.ReviewedAt.UTC().Format(time.RFC3339)
+			dto.ReviewedAt = &t
+		}
+		res.Reviewers = append(res.Reviewers, dto)
+	}
+	res.MissingReviewers = full.MissingReviewers
 	if !full.CreatedAt.IsZero() {
-		resp.PR.CreatedAt = full.CreatedAt.UTC().Format(time.RFC3339)
+		res.CreatedAt = full.CreatedAt.UTC().Format(time.RFC3339)
 	}
 	if full.MergedAt != nil {
 		t := full.MergedAt.UTC().Format(time.RFC3339)
-		resp.PR.MergedAt = &t
+		res.MergedAt = &t
+	}
+	if full.ClosedAt != nil {
+		t := full.ClosedAt.UTC().Format(time.RFC3339)
+		res.ClosedAt = &t
 	}
-	return resp
+	res.ForceMerged = full.ForceMerged
+	res.MergedBy = full.MergedBy
+	return res
 }
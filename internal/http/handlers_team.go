@@ -21,6 +21,21 @@ func NewTeamHandler(teamService service.TeamService) *TeamHandler {
 func (h *TeamHandler) Register(r *gin.RouterGroup) {
 	r.POST("/team/add", h.AddTeam)
 	r.GET("/team/get", h.GetTeam)
+	r.POST("/team/setReviewStrategy", h.SetReviewStrategy)
+	r.POST("/team/setReviewCapacity", h.SetReviewCapacity)
+	r.GET("/team/settings", h.GetSettings)
+	r.POST("/team/settings", h.UpdateSettings)
+	r.GET("/team/fallbacks", h.GetFallbacks)
+	r.POST("/team/fallbacks", h.SetFallbacks)
+	r.GET("/team/codeOwners", h.GetCodeOwners)
+	r.POST("/team/codeOwners", h.SetCodeOwners)
+	r.POST("/team/addMembers", h.AddMembers)
+	r.POST("/team/removeMembers", h.RemoveMembers)
+	r.POST("/team/moveMember", h.MoveMember)
+	r.POST("/team/rename", h.RenameTeam)
+	r.POST("/team/delete", h.DeleteTeam)
+	r.GET("/team/tree", h.GetTree)
+	r.GET("/team/list", h.ListTeams)
 }
 
 func (h *TeamHandler) AddTeam(c *gin.Context) {
@@ -47,6 +62,8 @@ func (h *TeamHandler) AddTeam(c *gin.Context) {
 			UserID:   u.UserID,
 			Username: u.Username,
 			IsActive: u.IsActive,
+			Tags:     u.Tags,
+			Role:     u.Role,
 		})
 	}
 
@@ -70,12 +87,22 @@ func (h *TeamHandler) GetTeam(c *gin.Context) {
 		return
 	}
 
+	available, err := h.teamService.Availability(c.Request.Context(), users)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
 	members := make([]domain.TeamMember, 0, len(users))
 	for _, u := range users {
+		isAvailable := available[u.UserID]
 		members = append(members, domain.TeamMember{
-			UserID:   u.UserID,
-			Username: u.Username,
-			IsActive: u.IsActive,
+			UserID:      u.UserID,
+			Username:    u.Username,
+			IsActive:    u.IsActive,
+			Tags:        u.Tags,
+			Role:        u.Role,
+			IsAvailable: &isAvailable,
 		})
 	}
 
@@ -84,3 +111,345 @@ func (h *TeamHandler) GetTeam(c *gin.Context) {
 		"members":   members,
 	})
 }
+
+// SetReviewStrategy is a shortcut for UpdateSettings with review_strategy only.
+func (h *TeamHandler) SetReviewStrategy(c *gin.Context) {
+	var req SetReviewStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	h.updateSettings(c, req.TeamName, domain.TeamSettings{ReviewStrategy: &req.ReviewStrategy})
+}
+
+// SetReviewCapacity is a shortcut for UpdateSettings with
+// default_max_open_reviews only.
+func (h *TeamHandler) SetReviewCapacity(c *gin.Context) {
+	var req SetTeamReviewCapacityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	h.updateSettings(c, req.TeamName, domain.TeamSettings{DefaultMaxOpenReviews: &req.DefaultMaxOpenReviews})
+}
+
+func (h *TeamHandler) GetSettings(c *gin.Context) {
+	teamName := c.Query("team_name")
+	if teamName == "" {
+		c.JSON(http.StatusBadRequest, errorBadRequest("team_name is required"))
+		return
+	}
+
+	team, err := h.teamService.GetSettings(c.Request.Context(), teamName)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, TeamResponse{Team: *team})
+}
+
+func (h *TeamHandler) UpdateSettings(c *gin.Context) {
+	var req TeamSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	h.updateSettings(c, req.TeamName, domain.TeamSettings{
+		ReviewStrategy:        req.ReviewStrategy,
+		DefaultMaxOpenReviews: req.DefaultMaxOpenReviews,
+		MinReviewers:          req.MinReviewers,
+		MaxReviewers:          req.MaxReviewers,
+		RequiredApprovals:     req.RequiredApprovals,
+		RequiredRole:          req.RequiredRole,
+		RequiredRoleCount:     req.RequiredRoleCount,
+		ParentTeam:            req.ParentTeam,
+		ClimbToParent:         req.ClimbToParent,
+	})
+}
+
+// updateSettings is the write path shared by all team settings endpoints.
+func (h *TeamHandler) updateSettings(c *gin.Context, teamName string, settings domain.TeamSettings) {
+	team, err := h.teamService.UpdateSettings(c.Request.Context(), teamName, settings)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUnknownStrategy):
+			c.JSON(http.StatusBadRequest, errorResponse("UNKNOWN_STRATEGY", "unknown review_strategy"))
+			return
+		case errors.Is(err, domain.ErrInvalidSettings):
+			c.JSON(http.StatusBadRequest, errorResponse("INVALID_SETTINGS", "settings must satisfy default_max_open_reviews >= 0, 0 <= min_reviewers <= max_reviewers, max_reviewers >= 1, required_approvals >= 0, 0 <= required_role_count <= max_reviewers with required_role set, parent_team must not be the team or its sub-team"))
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team or parent team not found"))
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, TeamResponse{Team: *team})
+}
+
+func (h *TeamHandler) GetFallbacks(c *gin.Context) {
+	teamName := c.Query("team_name")
+	if teamName == "" {
+		c.JSON(http.StatusBadRequest, errorBadRequest("team_name is required"))
+		return
+	}
+
+	fallbacks, err := h.teamService.GetFallbacks(c.Request.Context(), teamName)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, TeamFallbacksResponse{TeamName: teamName, FallbackTeams: fallbacks})
+}
+
+func (h *TeamHandler) SetFallbacks(c *gin.Context) {
+	var req TeamFallbacksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	fallbacks, err := h.teamService.SetFallbacks(c.Request.Context(), req.TeamName, req.FallbackTeams)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidSettings):
+			c.JSON(http.StatusBadRequest, errorResponse("INVALID_SETTINGS", "fallback teams must be distinct and differ from the team itself"))
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team or fallback team not found"))
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, TeamFallbacksResponse{TeamName: req.TeamName, FallbackTeams: fallbacks})
+}
+
+func (h *TeamHandler) GetCodeOwners(c *gin.Context) {
+	teamName := c.Query("team_name")
+	if teamName == "" {
+		c.JSON(http.StatusBadRequest, errorBadRequest("team_name is required"))
+		return
+	}
+
+	rules, err := h.teamService.GetCodeOwners(c.Request.Context(), teamName)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, TeamCodeOwnersResponse{TeamName: teamName, Rules: rules})
+}
+
+func (h *TeamHandler) SetCodeOwners(c *gin.Context) {
+	var req TeamCodeOwnersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	rules, err := h.teamService.SetCodeOwners(c.Request.Context(), req.TeamName, req.Rules)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidSettings):
+			c.JSON(http.StatusBadRequest, errorResponse("INVALID_SETTINGS", "every rule needs a pattern and at least one owner"))
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team or owner not found"))
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, TeamCodeOwnersResponse{TeamName: req.TeamName, Rules: rules})
+}
+
+func (h *TeamHandler) AddMembers(c *gin.Context) {
+	var req TeamMembersAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	users, err := h.teamService.AddMembers(c.Request.Context(), req.TeamName, req.Members)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	resp := TeamAddResponse{}
+	resp.Team.TeamName = req.TeamName
+	for _, u := range users {
+		resp.Team.Members = append(resp.Team.Members, domain.TeamMember{
+			UserID:   u.UserID,
+			Username: u.Username,
+			IsActive: u.IsActive,
+			Tags:     u.Tags,
+			Role:     u.Role,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *TeamHandler) RemoveMembers(c *gin.Context) {
+	var req TeamMembersRemoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	report, err := h.teamService.RemoveMembers(c.Request.Context(), req.TeamName, req.UserIDs, req.ReassignReviews)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team not found or user is not its member"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *TeamHandler) MoveMember(c *gin.Context) {
+	var req TeamMoveMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	report, err := h.teamService.MoveMember(c.Request.Context(), req.UserID, req.TeamName, req.ReassignReviews)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team or user not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *TeamHandler) RenameTeam(c *gin.Context) {
+	var req TeamRenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	team, err := h.teamService.RenameTeam(c.Request.Context(), req.TeamName, req.NewTeamName)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrTeamExists):
+			c.JSON(http.StatusBadRequest, errorResponse("TEAM_EXISTS", "new_team_name already exists"))
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team not found"))
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, TeamResponse{Team: *team})
+}
+
+func (h *TeamHandler) DeleteTeam(c *gin.Context) {
+	var req TeamDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	report, err := h.teamService.DeleteTeam(c.Request.Context(), req.TeamName, domain.TeamDeleteOptions{
+		Cascade: req.Cascade,
+		MoveTo:  req.MoveTo,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrTeamNotEmpty):
+			c.JSON(http.StatusConflict, errorResponse("TEAM_NOT_EMPTY", "team has members or reviewers on open pull requests, delete with cascade"))
+			return
+		case errors.Is(err, domain.ErrInvalidSettings):
+			c.JSON(http.StatusBadRequest, errorResponse("INVALID_SETTINGS", "move_to requires cascade and must differ from the deleted team"))
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team or move_to team not found"))
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *TeamHandler) GetTree(c *gin.Context) {
+	teams, err := h.teamService.Tree(c.Request.Context(), c.Query("team_name"))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, TeamTreeResponse{Teams: teams})
+}
+
+func (h *TeamHandler) ListTeams(c *gin.Context) {
+	var req TeamListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	page, err := h.teamService.ListTeams(c.Request.Context(), domain.TeamFilter{
+		NamePrefix: req.Prefix,
+		Limit:      req.Limit,
+		Cursor:     req.Cursor,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, errorResponse("INVALID_FILTER", "invalid limit (1..100) or cursor"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, TeamListResponse{Teams: page.Teams, NextCursor: page.NextCursor})
+}
@@ -26,7 +26,10 @@ func NewUserHandler(userSvc service.UserService, prSvc service.PRService, statsR
 
 func (h *UserHandler) Register(r *gin.RouterGroup) {
 	r.POST("/users/setIsActive", h.SetIsActive)
+	r.POST("/users/deactivate", h.Deactivate)
 	r.GET("/users/getReview", h.GetReview)
+	r.POST("/users/setMaxOpenReviews", h.SetMaxOpenReviews)
+	r.GET("/users/getReviewCapacity", h.GetReviewCapacity)
 	r.GET("/stats", h.Stats) // дополнительный эндпоинт
 }
 
@@ -37,6 +40,27 @@ func (h *UserHandler) SetIsActive(c *gin.Context) {
 		return
 	}
 
+	if !req.IsActive && req.ReassignReviews {
+		report, err := h.userService.DeactivateUsers(c.Request.Context(), []string{req.UserID}, true)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "user not found"))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+			return
+		}
+
+		user, err := h.userService.GetByID(c.Request.Context(), req.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, UserResponse{User: userToDTO(user), Reassignment: report})
+		return
+	}
+
 	user, err := h.userService.SetIsActive(c.Request.Context(), req.UserID, req.IsActive)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
@@ -47,31 +71,101 @@ func (h *UserHandler) SetIsActive(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, UserResponse{User: *user})
+	c.JSON(http.StatusOK, UserResponse{User: userToDTO(user)})
 }
 
-func (h *UserHandler) GetReview(c *gin.Context) {
+func (h *UserHandler) Deactivate(c *gin.Context) {
+	var req DeactivateUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	report, err := h.userService.DeactivateUsers(c.Request.Context(), req.UserIDs, req.ReassignReviews)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "user not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *UserHandler) SetMaxOpenReviews(c *gin.Context) {
+	var req SetMaxOpenReviewsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+
+	user, err := h.userService.SetMaxOpenReviews(c.Request.Context(), req.UserID, req.MaxOpenReviews)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "user not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, UserResponse{User: userToDTO(user)})
+}
+
+func (h *UserHandler) GetReviewCapacity(c *gin.Context) {
 	userID := c.Query("user_id")
 	if userID == "" {
 		c.JSON(http.StatusBadRequest, errorBadRequest("user_id is required"))
 		return
 	}
 
+	capacity, err := h.userService.GetReviewCapacity(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "user not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, ReviewCapacityResponse{Capacity: *capacity})
+}
+
+func (h *UserHandler) GetReview(c *gin.Context) {
+	var req GetReviewRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBadRequest(err.Error()))
+		return
+	}
+	userID := req.UserID
+
 	if _, err := h.userService.GetByID(c.Request.Context(), userID); err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
 		return
 
 	}
 
-	prs, err := h.prService.GetReviewPRs(c.Request.Context(), userID)
+	page, err := h.prService.GetReviewPRs(c.Request.Context(), userID, domain.ReviewFilter{
+		Status: domain.PRStatus(req.Status),
+		Limit:  req.Limit,
+		Cursor: req.Cursor,
+	})
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, errorResponse("INVALID_FILTER", "invalid status, limit (0..100) or cursor"))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL", err.Error()))
 		return
 	}
 
 	resp := GetReviewResponse{
 		UserID:       userID,
-		PullRequests: prs,
+		PullRequests: page.PullRequests,
+		NextCursor:   page.NextCursor,
 	}
 	c.JSON(http.StatusOK, resp)
 }
@@ -84,3 +178,17 @@ func (h *UserHandler) Stats(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, StatsResponse{Assignments: stats})
 }
+
+func userToDTO(u *domain.User) UserDTO {
+	return UserDTO{
+		UserID:         u.UserID,
+		Username:       u.Username,
+		TeamName:       u.TeamName,
+		Teams:          u.Teams,
+		IsActive:       u.IsActive,
+		MaxOpenReviews: u.MaxOpenReviews,
+		Tags:           u.Tags,
+		Role:           u.Role,
+		IsAdmin:        u.IsAdmin,
+	}
+}
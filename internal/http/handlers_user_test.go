@@ -0,0 +1,76 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Detsl735/avito-test/internal/domain"
+	"github.com/Detsl735/avito-test/internal/repository"
+	"github.com/Detsl735/avito-test/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupTestRouter(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, repository.AutoMigrate(db))
+
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	prRepo := repository.NewPRRepository(db)
+	unavailRepo := repository.NewUnavailabilityRepository(db)
+
+	selectors, err := service.NewSelectorSet(service.StrategyRandom, prRepo.CountOpenReviews)
+	require.NoError(t, err)
+
+	prSvc := service.NewPRService(db, prRepo, userRepo, teamRepo, unavailRepo, selectors, service.NewSeedSource(1))
+	teamSvc := service.NewTeamService(db, teamRepo, userRepo, unavailRepo, prSvc)
+	userSvc := service.NewUserService(db, userRepo, teamRepo, prRepo, prSvc)
+	availabilitySvc := service.NewAvailabilityService(db, unavailRepo, userRepo)
+
+	return NewRouter(teamSvc, userSvc, prSvc, availabilitySvc, repository.NewStatsRepository(db)), db
+}
+
+func TestUserHandler_SetMaxOpenReviews_UsesSnakeCaseKeys(t *testing.T) {
+	router, db := setupTestRouter(t)
+
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend"}).Error)
+	require.NoError(t, repository.NewUserRepository(db).UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true, Tags: []string{"go"}, Role: "senior"},
+	}))
+
+	body, err := json.Marshal(SetMaxOpenReviewsRequest{UserID: "u1", MaxOpenReviews: new(int)})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/users/setMaxOpenReviews", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp map[string]map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, map[string]any{
+		"user_id":          "u1",
+		"username":         "Alice",
+		"team_name":        "backend",
+		"teams":            []any{"backend"},
+		"is_active":        true,
+		"max_open_reviews": float64(0),
+		"tags":             []any{"go"},
+		"role":             "senior",
+		"is_admin":         false,
+	}, resp["user"])
+}
@@ -12,6 +12,7 @@ func NewRouter(
 	teamSvc service.TeamService,
 	userSvc service.UserService,
 	prSvc service.PRService,
+	availabilitySvc service.AvailabilityService,
 	statsRepo repository.StatsRepository,
 ) *gin.Engine {
 	r := gin.Default()
@@ -25,6 +26,7 @@ func NewRouter(
 		NewTeamHandler(teamSvc).Register(api)
 		NewUserHandler(userSvc, prSvc, statsRepo).Register(api)
 		NewPRHandler(prSvc).Register(api)
+		NewAvailabilityHandler(availabilitySvc).Register(api)
 	}
 
 	return r
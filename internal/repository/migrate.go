@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"github.com/Detsl735/avito-test/internal/domain"
+	"gorm.io/gorm"
+)
+
+// Models returns every model stored in the database.
+func Models() []any {
+	return []any{
+		&domain.Team{},
+		&domain.TeamFallback{},
+		&domain.CodeOwnerRule{},
+		&domain.User{},
+		&domain.TeamMembership{},
+		&domain.PullRequest{},
+		&domain.Reviewer{},
+		&domain.AssignmentDecision{},
+		&domain.Unavailability{},
+	}
+}
+
+// AutoMigrate brings the schema of all Models up to date and moves legacy
+// data into it.
+func AutoMigrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(Models()...); err != nil {
+		return err
+	}
+	return MigrateTeamMemberships(db)
+}
+
+// MigrateTeamMemberships moves the legacy users.team_name column into
+// team_memberships as the primary team of each user and drops the column.
+// It does nothing once the column is gone. It mirrors
+// migrations/00002_team_memberships.sql for databases set up by AutoMigrate.
+func MigrateTeamMemberships(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&domain.User{}, "team_name") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO team_memberships (user_id, team_name, is_primary)
+			SELECT u.user_id, u.team_name, ? FROM users u
+			WHERE u.team_name <> ''
+			AND NOT EXISTS (SELECT 1 FROM team_memberships m WHERE m.user_id = u.user_id)`, true).Error
+		if err != nil {
+			return err
+		}
+
+		m := tx.Migrator()
+		if m.HasIndex(&domain.User{}, "idx_users_team_name") {
+			if err := m.DropIndex(&domain.User{}, "idx_users_team_name"); err != nil {
+				return err
+			}
+		}
+		return m.DropColumn(&domain.User{}, "team_name")
+	})
+}
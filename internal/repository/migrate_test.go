@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Detsl735/avito-test/internal/domain"
+	"github.com/stretchr/testify/require"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupRepoTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	require.NoError(t, AutoMigrate(db))
+
+	return db
+}
+
+func TestMigrateTeamMemberships(t *testing.T) {
+	db := setupRepoTestDB(t)
+
+	require.NoError(t, db.Exec("ALTER TABLE users ADD COLUMN `team_name` TEXT NOT NULL DEFAULT ''").Error)
+	require.NoError(t, db.Exec("CREATE INDEX idx_users_team_name ON users (team_name)").Error)
+	require.NoError(t, db.Exec(`INSERT INTO users (user_id, username, team_name, is_active, role) VALUES
+		('u1', 'Alice', 'backend', true, ''),
+		('u2', 'Bob', '', true, '')`).Error)
+
+	require.NoError(t, MigrateTeamMemberships(db))
+	require.False(t, db.Migrator().HasColumn(&domain.User{}, "team_name"))
+	require.NoError(t, MigrateTeamMemberships(db))
+
+	users, err := NewUserRepository(db).GetByIDs(context.Background(), []string{"u1", "u2"})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	for _, u := range users {
+		switch u.UserID {
+		case "u1":
+			require.Equal(t, "backend", u.TeamName)
+			require.Equal(t, []string{"backend"}, u.Teams)
+		case "u2":
+			require.Empty(t, u.TeamName)
+			require.Empty(t, u.Teams)
+		}
+	}
+}
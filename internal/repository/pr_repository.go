@@ -2,16 +2,26 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/Detsl735/avito-test/internal/domain"
 	"gorm.io/gorm"
 )
 
 type PRRepository interface {
-	Create(ctx context.Context, pr domain.PullRequest, reviewers []string) (*domain.PullRequestFull, error)
+	Create(ctx context.Context, pr domain.PullRequest, reviewers []domain.Reviewer) (*domain.PullRequestFull, error)
 	GetByID(ctx context.Context, id string) (*domain.PullRequestFull, error)
-	Update(ctx context.Context, pr domain.PullRequest, reviewers []string) (*domain.PullRequestFull, error)
-	GetByReviewer(ctx context.Context, userID string) ([]domain.PullRequestShort, error)
+	List(ctx context.Context, f domain.PRFilter) (*domain.PRPage, error)
+	Update(ctx context.Context, pr domain.PullRequest, reviewers []domain.Reviewer) (*domain.PullRequestFull, error)
+	GetByReviewer(ctx context.Context, userID string, f domain.ReviewFilter) (*domain.ReviewPage, error)
+	CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int64, error)
+	GetOpenBorrowedFrom(ctx context.Context, teamName string) ([]domain.Reviewer, error)
+	UpdateReviewer(ctx context.Context, rv domain.Reviewer) error
+	SaveDecision(ctx context.Context, d *domain.AssignmentDecision) error
+	GetLastDecision(ctx context.Context, prID string) (*domain.AssignmentDecision, error)
 }
 
 type prRepository struct {
@@ -22,35 +32,27 @@ func NewPRRepository(db *gorm.DB) PRRepository {
 	return &prRepository{db: db}
 }
 
-func (r *prRepository) Create(ctx context.Context, pr domain.PullRequest, reviewers []string) (*domain.PullRequestFull, error) {
-	tx := r.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return nil, tx.Error
-	}
-
-	if err := tx.Create(&pr).Error; err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-
-	for _, uid := range reviewers {
-		if err := tx.Create(&domain.Reviewer{
-			PullRequestID: pr.PullRequestID,
-			UserID:        uid,
-		}).Error; err != nil {
-			tx.Rollback()
-			return nil, err
+func (r *prRepository) Create(ctx context.Context, pr domain.PullRequest, reviewers []domain.Reviewer) (*domain.PullRequestFull, error) {
+	created := make([]domain.Reviewer, 0, len(reviewers))
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&pr).Error; err != nil {
+			return err
 		}
-	}
 
-	if err := tx.Commit().Error; err != nil {
+		for _, rv := range reviewers {
+			prepareReviewer(&rv, pr.PullRequestID)
+			if err := tx.Create(&rv).Error; err != nil {
+				return err
+			}
+			created = append(created, rv)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return &domain.PullRequestFull{
-		PullRequest:       pr,
-		AssignedReviewers: reviewers,
-	}, nil
+	return newPullRequestFull(pr, created), nil
 }
 
 func (r *prRepository) GetByID(ctx context.Context, id string) (*domain.PullRequestFull, error) {
@@ -60,78 +62,333 @@ func (r *prRepository) GetByID(ctx context.Context, id string) (*domain.PullRequ
 	}
 
 	var reviewers []domain.Reviewer
-	if err := r.db.WithContext(ctx).Where("pull_request_id = ?", id).Find(&reviewers).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("pull_request_id = ?", id).Order("id").Find(&reviewers).Error; err != nil {
 		return nil, err
 	}
 
-	res := &domain.PullRequestFull{PullRequest: pr}
-	for _, rv := range reviewers {
-		res.AssignedReviewers = append(res.AssignedReviewers, rv.UserID)
-	}
-	return res, nil
+	return newPullRequestFull(pr, reviewers), nil
 }
 
-func (r *prRepository) Update(ctx context.Context, pr domain.PullRequest, reviewers []string) (*domain.PullRequestFull, error) {
-	tx := r.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return nil, tx.Error
+// List returns a page of pull requests matching f, ordered by f.Sort and then
+// by id. Pagination is keyset based: the cursor holds the sort key of the
+// last returned row. An undecodable cursor gives domain.ErrInvalidFilter.
+func (r *prRepository) List(ctx context.Context, f domain.PRFilter) (*domain.PRPage, error) {
+	q := r.db.WithContext(ctx).Model(&domain.PullRequest{})
+
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if f.AuthorID != "" {
+		q = q.Where("author_id = ?", f.AuthorID)
+	}
+	if f.ReviewerID != "" {
+		q = q.Where("pull_request_id IN (?)",
+			r.db.Model(&domain.Reviewer{}).Select("pull_request_id").Where("user_id = ?", f.ReviewerID))
+	}
+	if f.TeamName != "" {
+		// a pull request belongs to the primary team of its author
+		q = q.Where("author_id IN (?)",
+			r.db.Model(&domain.TeamMembership{}).Select("user_id").Where("team_name = ? AND is_primary = ?", f.TeamName, true))
+	}
+	if f.CreatedFrom != nil {
+		q = q.Where("created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		q = q.Where("created_at < ?", *f.CreatedTo)
+	}
+	if f.MergedFrom != nil {
+		q = q.Where("merged_at >= ?", *f.MergedFrom)
+	}
+	if f.MergedTo != nil {
+		q = q.Where("merged_at < ?", *f.MergedTo)
+	}
+	if f.NameContains != "" {
+		q = q.Where("LOWER(pull_request_name) LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(f.NameContains))+"%")
 	}
 
-	if err := tx.Save(&pr).Error; err != nil {
-		tx.Rollback()
-		return nil, err
+	column := "created_at"
+	if f.Sort == domain.PRSortName {
+		column = "pull_request_name"
+	}
+	cmp, dir := ">", "ASC"
+	if f.Desc {
+		cmp, dir = "<", "DESC"
 	}
 
-	if reviewers != nil {
-		if err := tx.Where("pull_request_id = ?", pr.PullRequestID).Delete(&domain.Reviewer{}).Error; err != nil {
-			tx.Rollback()
-			return nil, err
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return nil, domain.ErrInvalidFilter
 		}
-		for _, uid := range reviewers {
-			if err := tx.Create(&domain.Reviewer{
-				PullRequestID: pr.PullRequestID,
-				UserID:        uid,
-			}).Error; err != nil {
-				tx.Rollback()
-				return nil, err
+		var value any = c.Value
+		if column == "created_at" {
+			t, err := time.Parse(time.RFC3339Nano, c.Value)
+			if err != nil {
+				return nil, domain.ErrInvalidFilter
 			}
+			value = t
 		}
+		q = q.Where("("+column+" "+cmp+" ? OR ("+column+" = ? AND pull_request_id "+cmp+" ?))", value, value, c.ID)
 	}
 
-	if err := tx.Commit().Error; err != nil {
+	var prs []domain.PullRequest
+	err := q.Order(column + " " + dir).Order("pull_request_id " + dir).Limit(f.Limit + 1).Find(&prs).Error
+	if err != nil {
 		return nil, err
 	}
 
-	return &domain.PullRequestFull{
-		PullRequest:       pr,
-		AssignedReviewers: reviewers,
-	}, nil
+	page := &domain.PRPage{PullRequests: []domain.PullRequestFull{}}
+	if len(prs) > f.Limit {
+		prs = prs[:f.Limit]
+		last := prs[len(prs)-1]
+		c := pageCursor{Value: last.PullRequestName, ID: last.PullRequestID}
+		if column == "created_at" {
+			c.Value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+		}
+		page.NextCursor = encodeCursor(c)
+	}
+	if len(prs) == 0 {
+		return page, nil
+	}
+
+	ids := make([]string, 0, len(prs))
+	for _, pr := range prs {
+		ids = append(ids, pr.PullRequestID)
+	}
+	var reviewers []domain.Reviewer
+	if err := r.db.WithContext(ctx).Where("pull_request_id IN ?", ids).Order("id").Find(&reviewers).Error; err != nil {
+		return nil, err
+	}
+	byPR := make(map[string][]domain.Reviewer, len(prs))
+	for _, rv := range reviewers {
+		byPR[rv.PullRequestID] = append(byPR[rv.PullRequestID], rv)
+	}
+
+	for _, pr := range prs {
+		page.PullRequests = append(page.PullRequests, *newPullRequestFull(pr, byPR[pr.PullRequestID]))
+	}
+	return page, nil
+}
+
+// pageCursor is the sort key of the last row of a page.
+type pageCursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeCursor(c pageCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
 }
 
-func (r *prRepository) GetByReviewer(ctx context.Context, userID string) ([]domain.PullRequestShort, error) {
+// escapeLike escapes LIKE wildcards in s with a backslash.
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// Update saves pr and, when reviewers is not nil, makes the reviewer set
+// match it. Rows of reviewers that stay assigned are kept untouched, rows of
+// removed ones are deleted and new ones are inserted.
+func (r *prRepository) Update(ctx context.Context, pr domain.PullRequest, reviewers []domain.Reviewer) (*domain.PullRequestFull, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&pr).Error; err != nil {
+			return err
+		}
+
+		if reviewers == nil {
+			return nil
+		}
+
+		var existing []domain.Reviewer
+		if err := tx.Where("pull_request_id = ?", pr.PullRequestID).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		keep := make(map[string]bool, len(reviewers))
+		for _, rv := range reviewers {
+			keep[rv.UserID] = true
+		}
+		have := make(map[string]bool, len(existing))
+		for _, rv := range existing {
+			if keep[rv.UserID] {
+				have[rv.UserID] = true
+				continue
+			}
+			if err := tx.Delete(&domain.Reviewer{}, rv.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, rv := range reviewers {
+			if have[rv.UserID] {
+				continue
+			}
+			prepareReviewer(&rv, pr.PullRequestID)
+			if err := tx.Create(&rv).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, pr.PullRequestID)
+}
+
+// prepareReviewer turns rv into a fresh assignment of pull request prID.
+func prepareReviewer(rv *domain.Reviewer, prID string) {
+	rv.ID = 0
+	rv.PullRequestID = prID
+	if rv.State == "" {
+		rv.State = domain.ReviewStatePending
+	}
+	if rv.AssignedAt.IsZero() {
+		rv.AssignedAt = time.Now().UTC()
+	}
+}
+
+func newPullRequestFull(pr domain.PullRequest, reviewers []domain.Reviewer) *domain.PullRequestFull {
+	res := &domain.PullRequestFull{PullRequest: pr, Reviewers: reviewers}
+	for _, rv := range reviewers {
+		res.AssignedReviewers = append(res.AssignedReviewers, rv.UserID)
+	}
+	return res
+}
+
+// GetByReviewer returns pull requests userID reviews ordered by creation time,
+// paginated the same way as List.
+func (r *prRepository) GetByReviewer(ctx context.Context, userID string, f domain.ReviewFilter) (*domain.ReviewPage, error) {
 	var rows []struct {
 		PullRequestID   string
 		PullRequestName string
 		AuthorID        string
 		Status          string
+		State           string
+		ReviewedAt      *time.Time
+		CreatedAt       time.Time
+		MergedAt        *time.Time
 	}
-	err := r.db.WithContext(ctx).Table("pull_requests pr").
-		Select("pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status").
+	q := r.db.WithContext(ctx).Table("pull_requests pr").
+		Select("pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, r.state, r.reviewed_at, pr.created_at, pr.merged_at").
 		Joins("JOIN reviewers r ON r.pull_request_id = pr.pull_request_id").
-		Where("r.user_id = ?", userID).
-		Scan(&rows).Error
-	if err != nil {
+		Where("r.user_id = ?", userID)
+
+	if f.Status != "" {
+		q = q.Where("pr.status = ?", f.Status)
+	}
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return nil, domain.ErrInvalidFilter
+		}
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, domain.ErrInvalidFilter
+		}
+		q = q.Where("(pr.created_at > ? OR (pr.created_at = ? AND pr.pull_request_id > ?))", t, t, c.ID)
+	}
+	q = q.Order("pr.created_at").Order("pr.pull_request_id")
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit + 1)
+	}
+	if err := q.Scan(&rows).Error; err != nil {
 		return nil, err
 	}
 
-	result := make([]domain.PullRequestShort, 0, len(rows))
+	page := &domain.ReviewPage{PullRequests: make([]domain.PullRequestShort, 0, len(rows))}
+	if f.Limit > 0 && len(rows) > f.Limit {
+		rows = rows[:f.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(pageCursor{
+			Value: last.CreatedAt.UTC().Format(time.RFC3339Nano),
+			ID:    last.PullRequestID,
+		})
+	}
+
 	for _, row := range rows {
-		result = append(result, domain.PullRequestShort{
+		page.PullRequests = append(page.PullRequests, domain.PullRequestShort{
 			PullRequestID:   row.PullRequestID,
 			PullRequestName: row.PullRequestName,
 			AuthorID:        row.AuthorID,
 			Status:          domain.PRStatus(row.Status),
+			ReviewState:     domain.ReviewState(row.State),
+			ReviewedAt:      row.ReviewedAt,
+			CreatedAt:       row.CreatedAt,
+			MergedAt:        row.MergedAt,
 		})
 	}
-	return result, nil
+	return page, nil
+}
+
+func (r *prRepository) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int64, error) {
+	res := make(map[string]int64, len(userIDs))
+	if len(userIDs) == 0 {
+		return res, nil
+	}
+
+	var rows []struct {
+		UserID string
+		Cnt    int64
+	}
+	err := r.db.WithContext(ctx).Table("reviewers r").
+		Select("r.user_id, count(*) as cnt").
+		Joins("JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id").
+		Where("pr.status = ? AND r.user_id IN ?", domain.PRStatusOpen, userIDs).
+		Group("r.user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		res[row.UserID] = row.Cnt
+	}
+	return res, nil
+}
+
+// GetOpenBorrowedFrom returns reviewers of OPEN pull requests that were
+// borrowed from teamName as a fallback or parent team.
+func (r *prRepository) GetOpenBorrowedFrom(ctx context.Context, teamName string) ([]domain.Reviewer, error) {
+	var reviewers []domain.Reviewer
+	err := r.db.WithContext(ctx).
+		Joins("JOIN pull_requests pr ON pr.pull_request_id = reviewers.pull_request_id").
+		Where("pr.status = ? AND reviewers.fallback_team = ?", domain.PRStatusOpen, teamName).
+		Order("reviewers.id").
+		Find(&reviewers).Error
+	if err != nil {
+		return nil, err
+	}
+	return reviewers, nil
+}
+
+func (r *prRepository) UpdateReviewer(ctx context.Context, rv domain.Reviewer) error {
+	return r.db.WithContext(ctx).Save(&rv).Error
+}
+
+func (r *prRepository) SaveDecision(ctx context.Context, d *domain.AssignmentDecision) error {
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now().UTC()
+	}
+	return r.db.WithContext(ctx).Create(d).Error
+}
+
+func (r *prRepository) GetLastDecision(ctx context.Context, prID string) (*domain.AssignmentDecision, error) {
+	var d domain.AssignmentDecision
+	err := r.db.WithContext(ctx).Where("pull_request_id = ?", prID).Order("id DESC").First(&d).Error
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
 }
@@ -10,6 +10,15 @@ import (
 type TeamRepository interface {
 	Create(ctx context.Context, team domain.Team) error
 	GetByName(ctx context.Context, teamName string) (*domain.Team, error)
+	GetAll(ctx context.Context) ([]domain.Team, error)
+	List(ctx context.Context, f domain.TeamFilter) (*domain.TeamPage, error)
+	Update(ctx context.Context, team domain.Team) error
+	Rename(ctx context.Context, oldName, newName string) error
+	Delete(ctx context.Context, teamName string) error
+	GetFallbacks(ctx context.Context, teamName string) ([]string, error)
+	SetFallbacks(ctx context.Context, teamName string, fallbacks []string) error
+	GetCodeOwners(ctx context.Context, teamName string) ([]domain.CodeOwnerRule, error)
+	SetCodeOwners(ctx context.Context, teamName string, rules []domain.CodeOwnerRule) error
 }
 
 type teamRepository struct {
@@ -31,3 +40,169 @@ func (r *teamRepository) GetByName(ctx context.Context, teamName string) (*domai
 	}
 	return &t, nil
 }
+
+func (r *teamRepository) GetAll(ctx context.Context) ([]domain.Team, error) {
+	var teams []domain.Team
+	err := r.db.WithContext(ctx).Order("team_name").Find(&teams).Error
+	return teams, err
+}
+
+// List returns teams ordered by name together with their member counts and
+// open-review load, computed in one aggregate query.
+func (r *teamRepository) List(ctx context.Context, f domain.TeamFilter) (*domain.TeamPage, error) {
+	members := r.db.Table("team_memberships m").
+		Select("m.team_name, COUNT(*) AS members, SUM(CASE WHEN u.is_active THEN 1 ELSE 0 END) AS active_members").
+		Joins("JOIN users u ON u.user_id = m.user_id").
+		Group("m.team_name")
+	reviews := r.db.Table("team_memberships m").
+		Select("m.team_name, COUNT(*) AS open_reviews").
+		Joins("JOIN reviewers r ON r.user_id = m.user_id").
+		Joins("JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id").
+		Where("pr.status = ?", domain.PRStatusOpen).
+		Group("m.team_name")
+
+	q := r.db.WithContext(ctx).Table("teams t").
+		Select("t.team_name, t.parent_team, "+
+			"COALESCE(mc.members, 0) AS members, "+
+			"COALESCE(mc.active_members, 0) AS active_members, "+
+			"COALESCE(rc.open_reviews, 0) AS open_reviews").
+		Joins("LEFT JOIN (?) mc ON mc.team_name = t.team_name", members).
+		Joins("LEFT JOIN (?) rc ON rc.team_name = t.team_name", reviews)
+
+	if f.NamePrefix != "" {
+		q = q.Where("t.team_name LIKE ? ESCAPE '\\'", escapeLike(f.NamePrefix)+"%")
+	}
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return nil, domain.ErrInvalidFilter
+		}
+		q = q.Where("t.team_name > ?", c.Value)
+	}
+
+	var teams []domain.TeamSummary
+	if err := q.Order("t.team_name").Limit(f.Limit + 1).Scan(&teams).Error; err != nil {
+		return nil, err
+	}
+
+	page := &domain.TeamPage{Teams: teams}
+	if page.Teams == nil {
+		page.Teams = []domain.TeamSummary{}
+	}
+	if len(teams) > f.Limit {
+		page.Teams = teams[:f.Limit]
+		page.NextCursor = encodeCursor(pageCursor{Value: page.Teams[f.Limit-1].TeamName})
+	}
+	return page, nil
+}
+
+func (r *teamRepository) Update(ctx context.Context, team domain.Team) error {
+	return r.db.WithContext(ctx).Save(&team).Error
+}
+
+// Rename changes the team's primary key and every row referring to it.
+func (r *teamRepository) Rename(ctx context.Context, oldName, newName string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := []struct {
+			model  any
+			column string
+		}{
+			{&domain.Team{}, "team_name"},
+			{&domain.Team{}, "parent_team"},
+			{&domain.TeamMembership{}, "team_name"},
+			{&domain.TeamFallback{}, "team_name"},
+			{&domain.TeamFallback{}, "fallback_team_name"},
+			{&domain.CodeOwnerRule{}, "team_name"},
+			{&domain.Reviewer{}, "fallback_team"},
+		}
+		for _, u := range updates {
+			if err := tx.Model(u.model).Where(u.column+" = ?", oldName).Update(u.column, newName).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes the team with its fallbacks, code owners and the fallback
+// links of other teams to it. Its sub-teams move to its parent and reviewers
+// borrowed from it count as borrowed from nowhere, i.e. assigned for the
+// author's team. Members are left to the caller.
+func (r *teamRepository) Delete(ctx context.Context, teamName string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var t domain.Team
+		if err := tx.First(&t, "team_name = ?", teamName).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Team{}).Where("parent_team = ?", teamName).Update("parent_team", t.ParentTeam).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("team_name = ? OR fallback_team_name = ?", teamName, teamName).Delete(&domain.TeamFallback{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("team_name = ?", teamName).Delete(&domain.CodeOwnerRule{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Reviewer{}).Where("fallback_team = ?", teamName).Update("fallback_team", "").Error; err != nil {
+			return err
+		}
+		return tx.Where("team_name = ?", teamName).Delete(&domain.Team{}).Error
+	})
+}
+
+func (r *teamRepository) GetFallbacks(ctx context.Context, teamName string) ([]string, error) {
+	var rows []domain.TeamFallback
+	err := r.db.WithContext(ctx).Where("team_name = ?", teamName).Order("position").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, row.FallbackTeamName)
+	}
+	return res, nil
+}
+
+func (r *teamRepository) SetFallbacks(ctx context.Context, teamName string, fallbacks []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_name = ?", teamName).Delete(&domain.TeamFallback{}).Error; err != nil {
+			return err
+		}
+
+		for i, name := range fallbacks {
+			if err := tx.Create(&domain.TeamFallback{
+				TeamName:         teamName,
+				FallbackTeamName: name,
+				Position:         i,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *teamRepository) GetCodeOwners(ctx context.Context, teamName string) ([]domain.CodeOwnerRule, error) {
+	var rules []domain.CodeOwnerRule
+	err := r.db.WithContext(ctx).Where("team_name = ?", teamName).Order("position").Find(&rules).Error
+	return rules, err
+}
+
+func (r *teamRepository) SetCodeOwners(ctx context.Context, teamName string, rules []domain.CodeOwnerRule) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_name = ?", teamName).Delete(&domain.CodeOwnerRule{}).Error; err != nil {
+			return err
+		}
+
+		for i, rule := range rules {
+			rule.ID = 0
+			rule.TeamName = teamName
+			rule.Position = i
+			if err := tx.Create(&rule).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
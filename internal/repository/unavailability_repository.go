@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Detsl735/avito-test/internal/domain"
+	"gorm.io/gorm"
+)
+
+type UnavailabilityRepository interface {
+	Create(ctx context.Context, u domain.Unavailability) (*domain.Unavailability, error)
+	GetByID(ctx context.Context, id int64) (*domain.Unavailability, error)
+	ListByUser(ctx context.Context, userID string) ([]domain.Unavailability, error)
+	Update(ctx context.Context, u domain.Unavailability) error
+	Delete(ctx context.Context, id int64) error
+	UnavailableAt(ctx context.Context, userIDs []string, at time.Time) (map[string]bool, error)
+}
+
+type unavailabilityRepository struct {
+	db *gorm.DB
+}
+
+func NewUnavailabilityRepository(db *gorm.DB) UnavailabilityRepository {
+	return &unavailabilityRepository{db: db}
+}
+
+func (r *unavailabilityRepository) Create(ctx context.Context, u domain.Unavailability) (*domain.Unavailability, error) {
+	if err := r.db.WithContext(ctx).Create(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *unavailabilityRepository) GetByID(ctx context.Context, id int64) (*domain.Unavailability, error) {
+	var u domain.Unavailability
+	if err := r.db.WithContext(ctx).First(&u, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *unavailabilityRepository) ListByUser(ctx context.Context, userID string) ([]domain.Unavailability, error) {
+	var res []domain.Unavailability
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("starts_at").Find(&res).Error
+	return res, err
+}
+
+func (r *unavailabilityRepository) Update(ctx context.Context, u domain.Unavailability) error {
+	return r.db.WithContext(ctx).Save(&u).Error
+}
+
+func (r *unavailabilityRepository) Delete(ctx context.Context, id int64) error {
+	res := r.db.WithContext(ctx).Delete(&domain.Unavailability{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UnavailableAt returns which of userIDs have a period covering at.
+func (r *unavailabilityRepository) UnavailableAt(ctx context.Context, userIDs []string, at time.Time) (map[string]bool, error) {
+	res := make(map[string]bool)
+	if len(userIDs) == 0 {
+		return res, nil
+	}
+
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&domain.Unavailability{}).
+		Where("user_id IN ? AND starts_at <= ? AND ends_at > ?", userIDs, at, at).
+		Distinct().
+		Pluck("user_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		res[id] = true
+	}
+	return res, nil
+}
@@ -11,7 +11,10 @@ type UserRepository interface {
 	UpsertMany(ctx context.Context, users []domain.User) error
 	GetByID(ctx context.Context, id string) (*domain.User, error)
 	GetByTeamName(ctx context.Context, teamName string) ([]domain.User, error)
+	GetByIDs(ctx context.Context, ids []string) ([]domain.User, error)
 	SetIsActive(ctx context.Context, id string, active bool) (*domain.User, error)
+	ChangeTeam(ctx context.Context, ids []string, fromTeam, toTeam string) error
+	SetMaxOpenReviews(ctx context.Context, id string, limit *int) (*domain.User, error)
 }
 
 type userRepository struct {
@@ -22,37 +25,44 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// UpsertMany creates or updates users and adds each of them to its
+// TeamName. The team becomes primary for users without one. The admin flag
+// is never changed here, it is only set in the database.
 func (r *userRepository) UpsertMany(ctx context.Context, users []domain.User) error {
-	tx := r.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return tx.Error
-	}
-
-	for _, u := range users {
-		var existing domain.User
-		err := tx.Where("user_id = ?", u.UserID).First(&existing).Error
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, u := range users {
+			var existing domain.User
+			err := tx.Where("user_id = ?", u.UserID).First(&existing).Error
+			if err != nil {
+				if err != gorm.ErrRecordNotFound {
+					return err
+				}
+				u.IsAdmin = false
 				if err := tx.Create(&u).Error; err != nil {
-					tx.Rollback()
 					return err
 				}
 			} else {
-				tx.Rollback()
-				return err
+				existing.Username = u.Username
+				existing.IsActive = u.IsActive
+				if u.Tags != nil {
+					existing.Tags = u.Tags
+				}
+				if u.Role != "" {
+					existing.Role = u.Role
+				}
+				if err := tx.Save(&existing).Error; err != nil {
+					return err
+				}
 			}
-		} else {
-			existing.Username = u.Username
-			existing.TeamName = u.TeamName
-			existing.IsActive = u.IsActive
-			if err := tx.Save(&existing).Error; err != nil {
-				tx.Rollback()
-				return err
+
+			if u.TeamName != "" {
+				if err := joinTeam(tx, u.UserID, u.TeamName); err != nil {
+					return err
+				}
 			}
 		}
-	}
-
-	return tx.Commit().Error
+		return nil
+	})
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
@@ -60,23 +70,171 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*domain.User,
 	if err := r.db.WithContext(ctx).First(&u, "user_id = ?", id).Error; err != nil {
 		return nil, err
 	}
+	if err := r.fillTeams(ctx, []*domain.User{&u}); err != nil {
+		return nil, err
+	}
 	return &u, nil
 }
 
+// GetByTeamName returns all members of the team, whether it is their
+// primary team or not.
 func (r *userRepository) GetByTeamName(ctx context.Context, teamName string) ([]domain.User, error) {
 	var users []domain.User
-	err := r.db.WithContext(ctx).Where("team_name = ?", teamName).Find(&users).Error
-	return users, err
+	err := r.db.WithContext(ctx).
+		Where("user_id IN (?)", r.db.Model(&domain.TeamMembership{}).Select("user_id").Where("team_name = ?", teamName)).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, r.fillTeams(ctx, userPtrs(users))
+}
+
+func (r *userRepository) GetByIDs(ctx context.Context, ids []string) ([]domain.User, error) {
+	var users []domain.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := r.db.WithContext(ctx).Where("user_id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, r.fillTeams(ctx, userPtrs(users))
 }
 
 func (r *userRepository) SetIsActive(ctx context.Context, id string, active bool) (*domain.User, error) {
-	var u domain.User
-	if err := r.db.WithContext(ctx).First(&u, "user_id = ?", id).Error; err != nil {
+	u, err := r.GetByID(ctx, id)
+	if err != nil {
 		return nil, err
 	}
 	u.IsActive = active
-	if err := r.db.WithContext(ctx).Save(&u).Error; err != nil {
+	if err := r.db.WithContext(ctx).Save(u).Error; err != nil {
 		return nil, err
 	}
-	return &u, nil
+	return u, nil
+}
+
+func (r *userRepository) SetMaxOpenReviews(ctx context.Context, id string, limit *int) (*domain.User, error) {
+	u, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	u.MaxOpenReviews = limit
+	if err := r.db.WithContext(ctx).Save(u).Error; err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// ChangeTeam replaces the membership of users in fromTeam with toTeam,
+// keeping whether it is primary. An empty fromTeam only joins toTeam, an
+// empty toTeam only leaves fromTeam. When the primary team is left, another
+// membership, if any, becomes primary.
+func (r *userRepository) ChangeTeam(ctx context.Context, ids []string, fromTeam, toTeam string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			var from domain.TeamMembership
+			err := tx.Where("user_id = ? AND team_name = ?", id, fromTeam).First(&from).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+			if err == nil {
+				if err := tx.Delete(&from).Error; err != nil {
+					return err
+				}
+			}
+
+			if toTeam != "" {
+				if err := joinTeam(tx, id, toTeam); err != nil {
+					return err
+				}
+				if from.IsPrimary {
+					if err := setPrimary(tx, id, toTeam); err != nil {
+						return err
+					}
+				}
+			} else if from.IsPrimary {
+				if err := promoteAny(tx, id); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// fillTeams sets TeamName and Teams of users from their memberships.
+func (r *userRepository) fillTeams(ctx context.Context, users []*domain.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.UserID)
+	}
+
+	var rows []domain.TeamMembership
+	err := r.db.WithContext(ctx).Where("user_id IN ?", ids).Order("is_primary DESC, team_name").Find(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	byUser := make(map[string][]domain.TeamMembership, len(users))
+	for _, m := range rows {
+		byUser[m.UserID] = append(byUser[m.UserID], m)
+	}
+	for _, u := range users {
+		u.TeamName = ""
+		u.Teams = []string{}
+		for _, m := range byUser[u.UserID] {
+			if m.IsPrimary {
+				u.TeamName = m.TeamName
+			}
+			u.Teams = append(u.Teams, m.TeamName)
+		}
+	}
+	return nil
+}
+
+// joinTeam adds userID to teamName unless already there. The membership is
+// primary when the user has no primary team yet.
+func joinTeam(tx *gorm.DB, userID, teamName string) error {
+	var memberships []domain.TeamMembership
+	if err := tx.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+		return err
+	}
+
+	hasPrimary := false
+	for _, m := range memberships {
+		if m.TeamName == teamName {
+			return nil
+		}
+		hasPrimary = hasPrimary || m.IsPrimary
+	}
+	return tx.Create(&domain.TeamMembership{UserID: userID, TeamName: teamName, IsPrimary: !hasPrimary}).Error
+}
+
+func setPrimary(tx *gorm.DB, userID, teamName string) error {
+	return tx.Model(&domain.TeamMembership{}).
+		Where("user_id = ?", userID).
+		Update("is_primary", gorm.Expr("team_name = ?", teamName)).Error
+}
+
+// promoteAny makes the first remaining team of userID by name primary.
+func promoteAny(tx *gorm.DB, userID string) error {
+	var m domain.TeamMembership
+	err := tx.Where("user_id = ?", userID).Order("team_name").First(&m).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return setPrimary(tx, userID, m.TeamName)
+}
+
+func userPtrs(users []domain.User) []*domain.User {
+	res := make([]*domain.User, 0, len(users))
+	for i := range users {
+		res = append(res, &users[i])
+	}
+	return res
 }
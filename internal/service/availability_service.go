@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Detsl735/avito-test/internal/domain"
+	"github.com/Detsl735/avito-test/internal/repository"
+	"gorm.io/gorm"
+)
+
+type AvailabilityService interface {
+	AddPeriod(ctx context.Context, period domain.Unavailability) (*domain.Unavailability, error)
+	ListPeriods(ctx context.Context, userID string) ([]domain.Unavailability, error)
+	UpdatePeriod(ctx context.Context, period domain.Unavailability) (*domain.Unavailability, error)
+	DeletePeriod(ctx context.Context, id int64) error
+}
+
+type availabilityService struct {
+	unavailRepo repository.UnavailabilityRepository
+	userRepo    repository.UserRepository
+	db          *gorm.DB
+}
+
+func NewAvailabilityService(
+	db *gorm.DB,
+	unavailRepo repository.UnavailabilityRepository,
+	userRepo repository.UserRepository,
+) AvailabilityService {
+	return &availabilityService{
+		unavailRepo: unavailRepo,
+		userRepo:    userRepo,
+		db:          db,
+	}
+}
+
+func (s *availabilityService) AddPeriod(ctx context.Context, period domain.Unavailability) (*domain.Unavailability, error) {
+	if !period.EndsAt.After(period.StartsAt) {
+		return nil, domain.ErrInvalidPeriod
+	}
+	if _, err := s.userRepo.GetByID(ctx, period.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	period.ID = 0
+	return s.unavailRepo.Create(ctx, period)
+}
+
+func (s *availabilityService) ListPeriods(ctx context.Context, userID string) ([]domain.Unavailability, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return s.unavailRepo.ListByUser(ctx, userID)
+}
+
+// UpdatePeriod changes dates and reason of an existing period, the owner
+// of a period cannot be changed.
+func (s *availabilityService) UpdatePeriod(ctx context.Context, period domain.Unavailability) (*domain.Unavailability, error) {
+	if !period.EndsAt.After(period.StartsAt) {
+		return nil, domain.ErrInvalidPeriod
+	}
+
+	existing, err := s.unavailRepo.GetByID(ctx, period.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	existing.StartsAt = period.StartsAt
+	existing.EndsAt = period.EndsAt
+	existing.Reason = period.Reason
+	if err := s.unavailRepo.Update(ctx, *existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (s *availabilityService) DeletePeriod(ctx context.Context, id int64) error {
+	if err := s.unavailRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
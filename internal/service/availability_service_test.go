@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Detsl735/avito-test/internal/domain"
+	"github.com/Detsl735/avito-test/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvailabilityService_CRUD(t *testing.T) {
+	db := setupUserTestDB(t)
+
+	userRepo := repository.NewUserRepository(db)
+	unavailRepo := repository.NewUnavailabilityRepository(db)
+	svc := NewAvailabilityService(db, unavailRepo, userRepo)
+
+	ctx := context.Background()
+	start := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+	}))
+
+	_, err := svc.AddPeriod(ctx, domain.Unavailability{UserID: "u1", StartsAt: start, EndsAt: start})
+	require.Equal(t, domain.ErrInvalidPeriod, err)
+
+	_, err = svc.AddPeriod(ctx, domain.Unavailability{UserID: "nobody", StartsAt: start, EndsAt: start.Add(time.Hour)})
+	require.Equal(t, domain.ErrNotFound, err)
+
+	period, err := svc.AddPeriod(ctx, domain.Unavailability{
+		UserID:   "u1",
+		StartsAt: start,
+		EndsAt:   start.Add(14 * 24 * time.Hour),
+		Reason:   "vacation",
+	})
+	require.NoError(t, err)
+	require.NotZero(t, period.ID)
+
+	updated, err := svc.UpdatePeriod(ctx, domain.Unavailability{
+		ID:       period.ID,
+		StartsAt: start,
+		EndsAt:   start.Add(7 * 24 * time.Hour),
+		Reason:   "short vacation",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "u1", updated.UserID)
+	require.Equal(t, "short vacation", updated.Reason)
+
+	periods, err := svc.ListPeriods(ctx, "u1")
+	require.NoError(t, err)
+	require.Len(t, periods, 1)
+	require.Equal(t, "short vacation", periods[0].Reason)
+
+	require.NoError(t, svc.DeletePeriod(ctx, period.ID))
+	require.Equal(t, domain.ErrNotFound, svc.DeletePeriod(ctx, period.ID))
+
+	periods, err = svc.ListPeriods(ctx, "u1")
+	require.NoError(t, err)
+	require.Empty(t, periods)
+}
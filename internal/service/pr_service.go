@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/Detsl735/avito-test/internal/domain"
@@ -13,64 +15,337 @@ import (
 
 type PRService interface {
 	CreatePR(ctx context.Context, id, name, authorID string) (*domain.PullRequestFull, error)
+	CreateDraftPR(ctx context.Context, id, name, authorID string) (*domain.PullRequestFull, error)
+	CreatePRWithOptions(ctx context.Context, id, name, authorID string, opts domain.CreatePROptions) (*domain.PullRequestFull, error)
+	MarkReady(ctx context.Context, id string) (*domain.PullRequestFull, error)
+	ClosePR(ctx context.Context, id string) (*domain.PullRequestFull, error)
+	ReopenPR(ctx context.Context, id string) (*domain.PullRequestFull, error)
 	MergePR(ctx context.Context, id string) (*domain.PullRequestFull, error)
+	ForceMergePR(ctx context.Context, id, mergedBy string) (*domain.PullRequestFull, error)
 	ReassignReviewer(ctx context.Context, prID, oldUserID string) (*domain.PullRequestFull, string, error)
-	GetReviewPRs(ctx context.Context, userID string) ([]domain.PullRequestShort, error)
+	ReassignReviewerTo(ctx context.Context, prID, oldUserID, newUserID string) (*domain.PullRequestFull, error)
+	AddReviewer(ctx context.Context, prID, actorID, userID string) (*domain.PullRequestFull, error)
+	RemoveReviewer(ctx context.Context, prID, actorID, userID string) (*domain.PullRequestFull, error)
+	GetReviewPRs(ctx context.Context, userID string, filter domain.ReviewFilter) (*domain.ReviewPage, error)
+	ListPRs(ctx context.Context, filter domain.PRFilter) (*domain.PRPage, error)
+	GetPR(ctx context.Context, id string) (*domain.PullRequestFull, error)
+	SubmitReview(ctx context.Context, prID, userID string, state domain.ReviewState) (*domain.PullRequestFull, error)
+	ReassignUserReviews(ctx context.Context, userIDs []string) (*domain.ReassignReport, error)
+	ReassignUserReviewsFrom(ctx context.Context, userIDs []string, teamName string) (*domain.ReassignReport, error)
+	ExplainAssignment(ctx context.Context, prID string) (*domain.AssignmentDecision, error)
+	// WithTx returns a service working inside transaction tx.
+	WithTx(tx *gorm.DB) PRService
 }
 
+// errOtherTeam marks a reviewer assigned for another team than requested.
+var errOtherTeam = errors.New("reviewer is assigned for another team")
+
 type prService struct {
-	prRepo   repository.PRRepository
-	userRepo repository.UserRepository
-	db       *gorm.DB
+	prRepo      repository.PRRepository
+	userRepo    repository.UserRepository
+	teamRepo    repository.TeamRepository
+	unavailRepo repository.UnavailabilityRepository
+	selectors   *SelectorSet
+	seeds       *SeedSource
+	db          *gorm.DB
+
+	// seed, rnd and decision belong to one assignment round, see newRound.
+	seed     int64
+	rnd      *rand.Rand
+	decision *domain.AssignmentDecision
+}
+
+func NewPRService(
+	db *gorm.DB,
+	prRepo repository.PRRepository,
+	userRepo repository.UserRepository,
+	teamRepo repository.TeamRepository,
+	unavailRepo repository.UnavailabilityRepository,
+	selectors *SelectorSet,
+	seeds *SeedSource,
+) PRService {
+	return &prService{
+		prRepo:      prRepo,
+		userRepo:    userRepo,
+		teamRepo:    teamRepo,
+		unavailRepo: unavailRepo,
+		selectors:   selectors,
+		seeds:       seeds,
+		db:          db,
+	}
 }
 
-func NewPRService(db *gorm.DB, prRepo repository.PRRepository, userRepo repository.UserRepository) PRService {
+func (s *prService) WithTx(tx *gorm.DB) PRService {
+	prRepo := repository.NewPRRepository(tx)
 	return &prService{
-		prRepo:   prRepo,
-		userRepo: userRepo,
-		db:       db,
+		prRepo:      prRepo,
+		userRepo:    repository.NewUserRepository(tx),
+		teamRepo:    repository.NewTeamRepository(tx),
+		unavailRepo: repository.NewUnavailabilityRepository(tx),
+		selectors:   s.selectors.WithLoad(prRepo.CountOpenReviews),
+		seeds:       s.seeds,
+		db:          tx,
+	}
+}
+
+// newRound returns a copy of the service for one assignment round of pull
+// request prID. Its selection draws from a random source with a fresh seed,
+// which is stored on the picked reviewers, and every considered user is noted
+// in the round decision.
+func (s *prService) newRound(operation, prID, authorID string) *prService {
+	c := *s
+	c.seed = s.seeds.Next()
+	c.rnd = rand.New(rand.NewSource(c.seed))
+	c.decision = &domain.AssignmentDecision{
+		PullRequestID: prID,
+		Operation:     operation,
+		AuthorID:      authorID,
+		Seed:          c.seed,
 	}
+	return &c
+}
 
+// saveDecision stores the decision of the round with its final reviewers.
+func (s *prService) saveDecision(ctx context.Context, reviewers []domain.Reviewer) error {
+	s.decision.Choose(reviewers)
+	return s.prRepo.SaveDecision(ctx, s.decision)
 }
 
 func (s *prService) CreatePR(ctx context.Context, id, name, authorID string) (*domain.PullRequestFull, error) {
+	return s.CreatePRWithOptions(ctx, id, name, authorID, domain.CreatePROptions{})
+}
+
+// CreateDraftPR creates a pull request in DRAFT status. Reviewers are not
+// assigned until it is marked ready.
+func (s *prService) CreateDraftPR(ctx context.Context, id, name, authorID string) (*domain.PullRequestFull, error) {
+	return s.CreatePRWithOptions(ctx, id, name, authorID, domain.CreatePROptions{Draft: true})
+}
+
+// CreatePRWithOptions creates a pull request. Owners of the changed files,
+// if the author's team has code owners rules, are preferred as reviewers.
+func (s *prService) CreatePRWithOptions(ctx context.Context, id, name, authorID string, opts domain.CreatePROptions) (*domain.PullRequestFull, error) {
 	_, err := s.prRepo.GetByID(ctx, id)
 	if err == nil {
 		return nil, domain.ErrPRExists
 	}
 
 	author, err := s.userRepo.GetByID(ctx, authorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	pr := domain.PullRequest{
+		PullRequestID:   id,
+		PullRequestName: name,
+		AuthorID:        authorID,
+		Status:          domain.PRStatusOpen,
+		CreatedAt:       time.Now().UTC(),
+		FilePaths:       opts.FilePaths,
+		Labels:          opts.Labels,
+	}
+	if opts.Draft {
+		pr.Status = domain.PRStatusDraft
+	}
+
+	if pr.Status != domain.PRStatusOpen {
+		return s.prRepo.Create(ctx, pr, nil)
+	}
 
-	users, err := s.userRepo.GetByTeamName(ctx, author.TeamName)
+	round := s.newRound(domain.AssignmentCreate, id, authorID)
+	reviewers, missing, err := round.assignReviewers(ctx, author, pr)
 	if err != nil {
 		return nil, err
 	}
 
-	candidates := make([]string, 0)
-	for _, u := range users {
-		if !u.IsActive {
-			continue
+	full, err := s.prRepo.Create(ctx, pr, reviewers)
+	if err != nil {
+		return nil, err
+	}
+	if err := round.saveDecision(ctx, full.Reviewers); err != nil {
+		return nil, err
+	}
+	full.MissingReviewers = missing
+	return full, nil
+}
+
+// MarkReady moves a DRAFT pull request to OPEN and assigns its reviewers.
+func (s *prService) MarkReady(ctx context.Context, id string) (*domain.PullRequestFull, error) {
+	return s.open(ctx, id, domain.PRStatusDraft)
+}
+
+// ReopenPR moves a CLOSED pull request back to OPEN. Reviewers are picked
+// again as for a new pull request and the rest are dropped. Those picked
+// again stay assigned but review from scratch, their verdicts are reset.
+func (s *prService) ReopenPR(ctx context.Context, id string) (*domain.PullRequestFull, error) {
+	return s.open(ctx, id, domain.PRStatusClosed)
+}
+
+func (s *prService) open(ctx context.Context, id string, from domain.PRStatus) (*domain.PullRequestFull, error) {
+	full, err := s.getForTransition(ctx, id, domain.PRStatusOpen)
+	if err != nil {
+		return nil, err
+	}
+	if full.Status != from {
+		return nil, domain.ErrInvalidTransition
+	}
+
+	author, err := s.userRepo.GetByID(ctx, full.AuthorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
 		}
-		if u.UserID == author.UserID {
+		return nil, err
+	}
+
+	round := s.newRound(domain.AssignmentOpen, id, full.AuthorID)
+	reviewers, missing, err := round.assignReviewers(ctx, author, full.PullRequest)
+	if err != nil {
+		return nil, err
+	}
+	if reviewers == nil {
+		// nil would keep the rows left from before the pull request was closed
+		reviewers = []domain.Reviewer{}
+	}
+
+	full.Status = domain.PRStatusOpen
+	full.ClosedAt = nil
+
+	updated, err := s.prRepo.Update(ctx, full.PullRequest, reviewers)
+	if err != nil {
+		return nil, err
+	}
+	for i := range updated.Reviewers {
+		rv := &updated.Reviewers[i]
+		if rv.State == domain.ReviewStatePending {
 			continue
 		}
-		candidates = append(candidates, u.UserID)
+		rv.State = domain.ReviewStatePending
+		rv.ReviewedAt = nil
+		if err := s.prRepo.UpdateReviewer(ctx, *rv); err != nil {
+			return nil, err
+		}
+	}
+	if err := round.saveDecision(ctx, updated.Reviewers); err != nil {
+		return nil, err
+	}
+	updated.MissingReviewers = missing
+	return updated, nil
+}
+
+// ClosePR abandons a DRAFT or OPEN pull request without merging it. Its
+// reviewers and their verdicts stay recorded as review history, but load
+// and capacity only count OPEN pull requests, so they are free to review
+// others.
+func (s *prService) ClosePR(ctx context.Context, id string) (*domain.PullRequestFull, error) {
+	full, err := s.getForTransition(ctx, id, domain.PRStatusClosed)
+	if err != nil {
+		return nil, err
 	}
 
-	assigned := pickRandom(candidates, 2)
+	now := time.Now().UTC()
+	full.Status = domain.PRStatusClosed
+	full.ClosedAt = &now
 
-	pr := domain.PullRequest{
-		PullRequestID:   id,
-		PullRequestName: name,
-		AuthorID:        authorID,
-		Status:          domain.PRStatusOpen,
-		CreatedAt:       time.Now().UTC(),
+	return s.prRepo.Update(ctx, full.PullRequest, nil)
+}
+
+// getForTransition loads a pull request and checks it may be moved to next.
+func (s *prService) getForTransition(ctx context.Context, id string, next domain.PRStatus) (*domain.PullRequestFull, error) {
+	full, err := s.prRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	if !full.Status.CanTransitionTo(next) {
+		return nil, domain.ErrInvalidTransition
+	}
+	return full, nil
+}
+
+// assignReviewers picks reviewers for pr of author. Code owners of the changed
+// files come first, then members needed by the team role rule, the rest is
+// filled from the author's team, borrowing from fallback teams when the team
+// cannot reach its minimum. It fails with ErrRoleRuleViolated when the role
+// rule cannot be met. It also returns how many reviewers are still lacking
+// to the minimum.
+func (s *prService) assignReviewers(ctx context.Context, author *domain.User, pr domain.PullRequest) ([]domain.Reviewer, int, error) {
+	team, err := s.loadTeam(ctx, author.TeamName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	exclude := map[string]bool{author.UserID: true}
+	reviewers, err := s.pickCodeOwners(ctx, team, pr, exclude, team.MaxReviewers)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return s.prRepo.Create(ctx, pr, assigned)
+	reviewers, err = s.pickRequiredRole(ctx, team, pr.Labels, reviewers, exclude)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	picked, err := s.pickFromTeam(ctx, team, pr.Labels, exclude, team.MaxReviewers-len(reviewers))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, id := range picked {
+		reviewers = append(reviewers, s.newReviewer(id, team, domain.ViaTeam))
+		exclude[id] = true
+	}
+
+	if len(picked) == 0 {
+		climbed, err := s.pickFromParents(ctx, team, pr.Labels, exclude, team.MaxReviewers-len(reviewers))
+		if err != nil {
+			return nil, 0, err
+		}
+		reviewers = append(reviewers, climbed...)
+	}
+
+	if len(reviewers) < team.MinReviewers {
+		borrowed, err := s.pickFromFallbacks(ctx, team.TeamName, pr.Labels, exclude, team.MinReviewers-len(reviewers))
+		if err != nil {
+			return nil, 0, err
+		}
+		reviewers = append(reviewers, borrowed...)
+	}
+
+	missing := 0
+	if len(reviewers) < team.MinReviewers {
+		missing = team.MinReviewers - len(reviewers)
+	}
+	return reviewers, missing, nil
 }
 
+// MergePR merges an OPEN pull request once the author's team approval rules
+// are satisfied. Merging an already merged pull request is a no-op.
 func (s *prService) MergePR(ctx context.Context, id string) (*domain.PullRequestFull, error) {
+	return s.merge(ctx, id, false, "")
+}
+
+// ForceMergePR merges a pull request bypassing approval rules and records
+// who did it. Only admins may force a merge.
+func (s *prService) ForceMergePR(ctx context.Context, id, mergedBy string) (*domain.PullRequestFull, error) {
+	admin, err := s.userRepo.GetByID(ctx, mergedBy)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	if !admin.IsAdmin {
+		return nil, domain.ErrForbidden
+	}
+	return s.merge(ctx, id, true, mergedBy)
+}
+
+func (s *prService) merge(ctx context.Context, id string, force bool, mergedBy string) (*domain.PullRequestFull, error) {
 	full, err := s.prRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -82,112 +357,1026 @@ func (s *prService) MergePR(ctx context.Context, id string) (*domain.PullRequest
 	if full.Status == domain.PRStatusMerged {
 		return full, nil
 	}
+	if !full.Status.CanTransitionTo(domain.PRStatusMerged) {
+		return nil, domain.ErrInvalidTransition
+	}
+
+	if !force {
+		if err := s.checkMergeable(ctx, full); err != nil {
+			return nil, err
+		}
+	}
 
 	now := time.Now().UTC()
 	full.Status = domain.PRStatusMerged
 	full.MergedAt = &now
+	full.ForceMerged = force
+	full.MergedBy = mergedBy
 
-	updated, err := s.prRepo.Update(ctx, full.PullRequest, full.AssignedReviewers)
+	updated, err := s.prRepo.Update(ctx, full.PullRequest, nil)
 	if err != nil {
 		return nil, err
 	}
 	return updated, nil
 }
 
+// checkMergeable returns ErrMergeBlocked when a reviewer still requests
+// changes or the pull request lacks approvals required by the author's team.
+func (s *prService) checkMergeable(ctx context.Context, full *domain.PullRequestFull) error {
+	approvals := 0
+	for _, rv := range full.Reviewers {
+		switch rv.State {
+		case domain.ReviewStateChangesRequested:
+			return fmt.Errorf("%w: %s requested changes", domain.ErrMergeBlocked, rv.UserID)
+		case domain.ReviewStateApproved:
+			approvals++
+		}
+	}
+
+	required := 0
+	author, err := s.userRepo.GetByID(ctx, full.AuthorID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if author != nil {
+		team, err := s.loadTeam(ctx, author.TeamName)
+		if err != nil {
+			return err
+		}
+		required = team.RequiredApprovals
+	}
+
+	if approvals < required {
+		return fmt.Errorf("%w: %d of %d required approvals", domain.ErrMergeBlocked, approvals, required)
+	}
+	return nil
+}
+
 func (s *prService) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*domain.PullRequestFull, string, error) {
-	full, err := s.prRepo.GetByID(ctx, prID)
+	return s.reassign(ctx, prID, oldUserID, "", "")
+}
+
+// ReassignReviewerTo replaces oldUserID with newUserID instead of a picked
+// teammate. newUserID must pass the same rules as a picked replacement,
+// otherwise ErrIneligibleReviewer is returned.
+func (s *prService) ReassignReviewerTo(ctx context.Context, prID, oldUserID, newUserID string) (*domain.PullRequestFull, error) {
+	full, _, err := s.reassign(ctx, prID, oldUserID, newUserID, "")
+	return full, err
+}
+
+func (s *prService) reassign(ctx context.Context, prID, oldUserID, requestedID, teamName string) (*domain.PullRequestFull, string, error) {
+	round := s.newRound(domain.AssignmentReassign, prID, "")
+	round.decision.ReplacedUserID = oldUserID
+	full, newUserID, err := round.reassignReviewer(ctx, prID, oldUserID, requestedID, teamName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, rv := range full.Reviewers {
+		if rv.UserID == newUserID {
+			if err := round.saveDecision(ctx, []domain.Reviewer{rv}); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	return full, newUserID, nil
+}
+
+// AddReviewer assigns userID to an OPEN pull request on behalf of actorID,
+// who must be its author or an admin. The same rules as for picked reviewers
+// apply: the pull request has fewer than MaxReviewers of the author's team,
+// the user is active, available, within review capacity, not the author and
+// belongs to the author's team or one of its fallback teams.
+func (s *prService) AddReviewer(ctx context.Context, prID, actorID, userID string) (*domain.PullRequestFull, error) {
+	full, author, err := s.getForEdit(ctx, prID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range full.AssignedReviewers {
+		if id == userID {
+			return nil, domain.ErrAlreadyAssigned
+		}
+	}
+
+	team, err := s.loadTeam(ctx, author.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	if len(full.Reviewers) >= team.MaxReviewers {
+		return nil, domain.ErrTooManyReviewers
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "", domain.ErrNotFound
+			return nil, domain.ErrNotFound
 		}
-		return nil, "", err
+		return nil, err
 	}
 
-	if full.Status == domain.PRStatusMerged {
-		return nil, "", domain.ErrPRMerged
+	fallbackTeam, err := s.checkEligible(ctx, author, user)
+	if err != nil {
+		return nil, err
+	}
+	if fallbackTeam != "" {
+		if team, err = s.loadTeam(ctx, fallbackTeam); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkAvailable(ctx, team, user); err != nil {
+		return nil, err
 	}
 
-	oldUser, err := s.userRepo.GetByID(ctx, oldUserID)
+	reviewers := append(full.Reviewers, domain.Reviewer{UserID: userID, FallbackTeam: fallbackTeam})
+	return s.prRepo.Update(ctx, full.PullRequest, reviewers)
+}
+
+// RemoveReviewer drops userID from the reviewers of an OPEN pull request on
+// behalf of actorID, who must be its author or an admin. Removing a reviewer
+// the role rule of the author's team depends on fails with
+// ErrRoleRuleViolated, such a reviewer can only be reassigned.
+func (s *prService) RemoveReviewer(ctx context.Context, prID, actorID, userID string) (*domain.PullRequestFull, error) {
+	full, author, err := s.getForEdit(ctx, prID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "", domain.ErrNotFound
+			return nil, domain.ErrNotFound
 		}
-		return nil, "", err
+		return nil, err
+	}
+	role, err := s.replacementRole(ctx, author, user, full.AssignedReviewers)
+	if err != nil {
+		return nil, err
 	}
 
-	found := false
-	for _, r := range full.AssignedReviewers {
-		if r == oldUserID {
-			found = true
-			break
+	reviewers := make([]domain.Reviewer, 0, len(full.Reviewers))
+	for _, rv := range full.Reviewers {
+		if rv.UserID != userID {
+			reviewers = append(reviewers, rv)
 		}
 	}
-	if !found {
-		return nil, "", domain.ErrNotAssigned
+	if len(reviewers) == len(full.Reviewers) {
+		return nil, domain.ErrNotAssigned
+	}
+	if role != "" {
+		return nil, domain.ErrRoleRuleViolated
 	}
 
-	users, err := s.userRepo.GetByTeamName(ctx, oldUser.TeamName)
+	return s.prRepo.Update(ctx, full.PullRequest, reviewers)
+}
+
+// checkAvailable fails with ErrIneligibleReviewer unless user is available
+// now and still has review capacity under the limits of team.
+func (s *prService) checkAvailable(ctx context.Context, team *domain.Team, user *domain.User) error {
+	// the check is not a part of an assignment round, nothing is recorded
+	scratch := *s
+	scratch.decision = &domain.AssignmentDecision{}
+	candidates, err := scratch.eligibleCandidates(ctx, team, []domain.User{*user}, nil)
 	if err != nil {
-		return nil, "", err
+		return err
+	}
+	if len(candidates) == 0 {
+		return domain.ErrIneligibleReviewer
 	}
+	return nil
+}
 
-	var candidates []string
-	for _, u := range users {
-		if !u.IsActive {
-			continue
+// getForEdit loads an OPEN pull request and its author and checks that
+// actorID may edit its reviewers.
+func (s *prService) getForEdit(ctx context.Context, prID, actorID string) (*domain.PullRequestFull, *domain.User, error) {
+	full, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrNotFound
 		}
-		if u.UserID == full.AuthorID {
-			continue
+		return nil, nil, err
+	}
+
+	if full.Status == domain.PRStatusMerged {
+		return nil, nil, domain.ErrPRMerged
+	}
+	if full.Status != domain.PRStatusOpen {
+		return nil, nil, domain.ErrPRNotOpen
+	}
+
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrNotFound
 		}
-		skip := false
-		for _, assigned := range full.AssignedReviewers {
-			if assigned == u.UserID {
-				skip = true
-				break
+		return nil, nil, err
+	}
+	if actor.UserID != full.AuthorID && !actor.IsAdmin {
+		return nil, nil, domain.ErrForbidden
+	}
+
+	author := actor
+	if actor.UserID != full.AuthorID {
+		author, err = s.userRepo.GetByID(ctx, full.AuthorID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, domain.ErrNotFound
 			}
+			return nil, nil, err
 		}
-		if skip {
-			continue
-		}
-		candidates = append(candidates, u.UserID)
 	}
+	return full, author, nil
+}
 
-	if len(candidates) == 0 {
-		return nil, "", domain.ErrNoCandidate
+// checkEligible reports whether user may review pull requests of author: the
+// user is active, is not the author and is a member of the author's team or
+// one of its fallback teams. For the latter, the fallback team is returned.
+func (s *prService) checkEligible(ctx context.Context, author, user *domain.User) (string, error) {
+	if user.UserID == author.UserID || !user.IsActive {
+		return "", domain.ErrIneligibleReviewer
+	}
+	if user.InTeam(author.TeamName) {
+		return "", nil
 	}
 
-	newUserID := candidates[rand.Intn(len(candidates))]
+	fallbacks, err := s.teamRepo.GetFallbacks(ctx, author.TeamName)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range fallbacks {
+		if user.InTeam(name) {
+			return name, nil
+		}
+	}
+	return "", domain.ErrIneligibleReviewer
+}
 
-	for i, rID := range full.AssignedReviewers {
-		if rID == oldUserID {
-			full.AssignedReviewers[i] = newUserID
-			break
+// ExplainAssignment returns the decision record of the last reviewer
+// assignment round of the pull request.
+func (s *prService) ExplainAssignment(ctx context.Context, prID string) (*domain.AssignmentDecision, error) {
+	if _, err := s.prRepo.GetByID(ctx, prID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
 		}
+		return nil, err
 	}
 
-	updated, err := s.prRepo.Update(ctx, full.PullRequest, full.AssignedReviewers)
+	d, err := s.prRepo.GetLastDecision(ctx, prID)
 	if err != nil {
-		return nil, "", err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
 	}
-	return updated, newUserID, nil
+	return d, nil
 }
 
-func (s *prService) GetReviewPRs(ctx context.Context, userID string) ([]domain.PullRequestShort, error) {
-	return s.prRepo.GetByReviewer(ctx, userID)
-}
+// reassignReviewer replaces oldUserID with requestedID, or with a reviewer
+// picked from the team oldUserID was assigned for or the author's fallbacks
+// when requestedID is empty. That team is the fallback team the reviewer was
+// borrowed from or the author's primary team. A non-empty teamName limits
+// reassignment to reviewers assigned for it, others fail with errOtherTeam.
+func (s *prService) reassignReviewer(ctx context.Context, prID, oldUserID, requestedID, teamName string) (*domain.PullRequestFull, string, error) {
+	full, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", domain.ErrNotFound
+		}
+		return nil, "", err
+	}
 
-func pickRandom(items []string, n int) []string {
-	if len(items) == 0 || n <= 0 {
-		return nil
+	if full.Status == domain.PRStatusMerged {
+		return nil, "", domain.ErrPRMerged
 	}
-	if len(items) <= n {
-		return items
+	if full.Status != domain.PRStatusOpen {
+		return nil, "", domain.ErrPRNotOpen
 	}
-	res := make([]string, len(items))
-	copy(res, items)
-	for i := range res {
-		j := rand.Intn(i + 1)
-		res[i], res[j] = res[j], res[i]
+
+	oldUser, err := s.userRepo.GetByID(ctx, oldUserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", domain.ErrNotFound
+		}
+		return nil, "", err
+	}
+
+	found := false
+	for _, r := range full.AssignedReviewers {
+		if r == oldUserID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, "", domain.ErrNotAssigned
+	}
+
+	author, err := s.userRepo.GetByID(ctx, full.AuthorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", domain.ErrNotFound
+		}
+		return nil, "", err
+	}
+
+	assignedTeam := author.TeamName
+	for _, rv := range full.Reviewers {
+		if rv.UserID == oldUserID && rv.FallbackTeam != "" {
+			assignedTeam = rv.FallbackTeam
+		}
+	}
+	if teamName == "" {
+		teamName = assignedTeam
+	} else if teamName != assignedTeam {
+		return nil, "", errOtherTeam
+	}
+	team, err := s.loadTeam(ctx, teamName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.decision.AuthorID = full.AuthorID
+	exclude := map[string]bool{full.AuthorID: true}
+	for _, id := range full.AssignedReviewers {
+		exclude[id] = true
+	}
+
+	role, err := s.replacementRole(ctx, author, oldUser, full.AssignedReviewers)
+	if err != nil {
+		return nil, "", err
+	}
+	if requestedID != "" {
+		replacement, err := s.requestedReplacement(ctx, full, author, team, role, exclude, requestedID)
+		if err != nil {
+			return nil, "", err
+		}
+		return s.replaceReviewer(ctx, full, oldUserID, replacement)
+	}
+	if role != "" {
+		// the old reviewer is needed by the role rule, only the same role may replace them
+		members, err := s.membersWithRole(ctx, team.TeamName, role)
+		if err != nil {
+			return nil, "", err
+		}
+		picked, err := s.pickFromUsers(ctx, team, members, full.Labels, exclude, 1)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(picked) == 0 {
+			return nil, "", domain.ErrRoleRuleViolated
+		}
+		return s.replaceReviewer(ctx, full, oldUserID, s.newReplacement(picked[0], team, author, domain.ViaRequiredRole))
+	}
+
+	picked, err := s.pickFromTeam(ctx, team, full.Labels, exclude, 1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var replacement domain.Reviewer
+	if len(picked) > 0 {
+		replacement = s.newReplacement(picked[0], team, author, domain.ViaTeam)
+	} else {
+		// the reviewer's own team is exhausted, go up to its parents and then
+		// borrow from the author's fallbacks
+		borrowed, err := s.pickFromParents(ctx, team, full.Labels, exclude, 1)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(borrowed) == 0 {
+			borrowed, err = s.pickFromFallbacks(ctx, author.TeamName, full.Labels, exclude, 1)
+			if err != nil {
+				return nil, "", err
+			}
+		}
+		if len(borrowed) == 0 {
+			return nil, "", domain.ErrNoCandidate
+		}
+		replacement = borrowed[0]
+	}
+
+	return s.replaceReviewer(ctx, full, oldUserID, replacement)
+}
+
+// requestedReplacement checks that requestedID could have been picked as the
+// replacement: a member of team, which is the old reviewer's one, or of the
+// author's fallback teams, with the required role if any, eligible and within
+// capacity.
+func (s *prService) requestedReplacement(
+	ctx context.Context,
+	full *domain.PullRequestFull,
+	author *domain.User,
+	team *domain.Team,
+	role string,
+	exclude map[string]bool,
+	requestedID string,
+) (domain.Reviewer, error) {
+	user, err := s.userRepo.GetByID(ctx, requestedID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.Reviewer{}, domain.ErrNotFound
+		}
+		return domain.Reviewer{}, err
+	}
+	if role != "" && user.Role != role {
+		return domain.Reviewer{}, domain.ErrIneligibleReviewer
+	}
+
+	if !user.InTeam(team.TeamName) {
+		fallbacks, err := s.teamRepo.GetFallbacks(ctx, author.TeamName)
+		if err != nil {
+			return domain.Reviewer{}, err
+		}
+		fallback := ""
+		for _, name := range fallbacks {
+			if user.InTeam(name) {
+				fallback = name
+				break
+			}
+		}
+		if fallback == "" {
+			return domain.Reviewer{}, domain.ErrIneligibleReviewer
+		}
+
+		team, err = s.loadTeam(ctx, fallback)
+		if err != nil {
+			return domain.Reviewer{}, err
+		}
+	}
+
+	candidates, err := s.eligibleCandidates(ctx, team, []domain.User{*user}, exclude)
+	if err != nil {
+		return domain.Reviewer{}, err
+	}
+	if len(candidates) == 0 {
+		return domain.Reviewer{}, domain.ErrIneligibleReviewer
+	}
+
+	rv := s.newReplacement(user.UserID, team, author, domain.ViaRequested)
+	rv.Strategy = ""
+	return rv, nil
+}
+
+// newReplacement builds a reviewer picked from team for a pull request of
+// author, marking it borrowed when team is not the author's one.
+func (s *prService) newReplacement(userID string, team *domain.Team, author *domain.User, via string) domain.Reviewer {
+	if team.TeamName != author.TeamName && via == domain.ViaTeam {
+		via = domain.ViaFallback
+	}
+	rv := s.newReviewer(userID, team, via)
+	if team.TeamName != author.TeamName {
+		rv.FallbackTeam = team.TeamName
+	}
+	return rv
+}
+
+// replacementRole returns the role the replacement of oldUser must have so
+// that the role rule of the author's team still holds, or "" if any will do.
+func (s *prService) replacementRole(ctx context.Context, author, oldUser *domain.User, assigned []string) (string, error) {
+	authorTeam, err := s.loadTeam(ctx, author.TeamName)
+	if err != nil {
+		return "", err
+	}
+	if authorTeam.RequiredRoleCount <= 0 || oldUser.Role != authorTeam.RequiredRole {
+		return "", nil
+	}
+
+	users, err := s.userRepo.GetByIDs(ctx, assigned)
+	if err != nil {
+		return "", err
+	}
+	remaining := 0
+	for _, u := range users {
+		if u.UserID != oldUser.UserID && u.Role == authorTeam.RequiredRole {
+			remaining++
+		}
+	}
+	if remaining >= authorTeam.RequiredRoleCount {
+		return "", nil
+	}
+	return authorTeam.RequiredRole, nil
+}
+
+// replaceReviewer swaps oldUserID for replacement on full and stores it.
+func (s *prService) replaceReviewer(ctx context.Context, full *domain.PullRequestFull, oldUserID string, replacement domain.Reviewer) (*domain.PullRequestFull, string, error) {
+	reviewers := make([]domain.Reviewer, 0, len(full.Reviewers))
+	for _, rv := range full.Reviewers {
+		if rv.UserID == oldUserID {
+			reviewers = append(reviewers, replacement)
+			continue
+		}
+		reviewers = append(reviewers, rv)
+	}
+
+	updated, err := s.prRepo.Update(ctx, full.PullRequest, reviewers)
+	if err != nil {
+		return nil, "", err
+	}
+	return updated, replacement.UserID, nil
+}
+
+// ReassignUserReviews moves every OPEN review of userIDs to another eligible
+// reviewer. Reviews without a replacement candidate stay where they are and
+// are listed in the report as not reassigned.
+func (s *prService) ReassignUserReviews(ctx context.Context, userIDs []string) (*domain.ReassignReport, error) {
+	return s.reassignUserReviews(ctx, userIDs, "")
+}
+
+// ReassignUserReviewsFrom works as ReassignUserReviews but only for reviews
+// the users were assigned as members of teamName, the rest is kept. It is
+// used when the reviewers are leaving teamName.
+func (s *prService) ReassignUserReviewsFrom(ctx context.Context, userIDs []string, teamName string) (*domain.ReassignReport, error) {
+	return s.reassignUserReviews(ctx, userIDs, teamName)
+}
+
+func (s *prService) reassignUserReviews(ctx context.Context, userIDs []string, teamName string) (*domain.ReassignReport, error) {
+	report := &domain.ReassignReport{
+		Moved:         []domain.ReviewMove{},
+		NotReassigned: []domain.ReviewMove{},
+	}
+
+	for _, userID := range userIDs {
+		page, err := s.prRepo.GetByReviewer(ctx, userID, domain.ReviewFilter{Status: domain.PRStatusOpen})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pr := range page.PullRequests {
+			move := domain.ReviewMove{PullRequestID: pr.PullRequestID, FromUserID: userID}
+			_, newUserID, err := s.reassign(ctx, pr.PullRequestID, userID, "", teamName)
+			if err != nil {
+				if errors.Is(err, errOtherTeam) {
+					continue
+				}
+				if errors.Is(err, domain.ErrNoCandidate) || errors.Is(err, domain.ErrRoleRuleViolated) {
+					move.Reason = err.Error()
+					report.NotReassigned = append(report.NotReassigned, move)
+					continue
+				}
+				return nil, err
+			}
+			move.ToUserID = newUserID
+			report.Moved = append(report.Moved, move)
+		}
+	}
+	return report, nil
+}
+
+// GetReviewPRs returns pull requests userID reviews, oldest first. A zero
+// limit returns all of them.
+func (s *prService) GetReviewPRs(ctx context.Context, userID string, filter domain.ReviewFilter) (*domain.ReviewPage, error) {
+	if filter.Limit < 0 || filter.Limit > maxListLimit {
+		return nil, domain.ErrInvalidFilter
+	}
+	switch filter.Status {
+	case "", domain.PRStatusDraft, domain.PRStatusOpen, domain.PRStatusMerged, domain.PRStatusClosed:
+	default:
+		return nil, domain.ErrInvalidFilter
+	}
+
+	return s.prRepo.GetByReviewer(ctx, userID, filter)
+}
+
+// GetPR returns the pull request with its reviewers and author.
+func (s *prService) GetPR(ctx context.Context, id string) (*domain.PullRequestFull, error) {
+	full, err := s.prRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	author, err := s.userRepo.GetByID(ctx, full.AuthorID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	full.Author = author
+	return full, nil
+}
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ListPRs returns a page of pull requests matching filter. A zero limit means
+// the default page size.
+func (s *prService) ListPRs(ctx context.Context, filter domain.PRFilter) (*domain.PRPage, error) {
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxListLimit {
+		return nil, domain.ErrInvalidFilter
+	}
+	switch filter.Status {
+	case "", domain.PRStatusDraft, domain.PRStatusOpen, domain.PRStatusMerged, domain.PRStatusClosed:
+	default:
+		return nil, domain.ErrInvalidFilter
+	}
+	switch filter.Sort {
+	case "":
+		filter.Sort = domain.PRSortCreatedAt
+	case domain.PRSortCreatedAt, domain.PRSortName:
+	default:
+		return nil, domain.ErrInvalidFilter
+	}
+
+	return s.prRepo.List(ctx, filter)
+}
+
+// SubmitReview records the verdict of an assigned reviewer on an OPEN pull request.
+func (s *prService) SubmitReview(ctx context.Context, prID, userID string, state domain.ReviewState) (*domain.PullRequestFull, error) {
+	if !state.IsVerdict() {
+		return nil, domain.ErrInvalidReviewState
+	}
+
+	full, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	if full.Status == domain.PRStatusMerged {
+		return nil, domain.ErrPRMerged
+	}
+	if full.Status != domain.PRStatusOpen {
+		return nil, domain.ErrPRNotOpen
+	}
+
+	for _, rv := range full.Reviewers {
+		if rv.UserID != userID {
+			continue
+		}
+
+		now := time.Now().UTC()
+		rv.State = state
+		rv.ReviewedAt = &now
+		if err := s.prRepo.UpdateReviewer(ctx, rv); err != nil {
+			return nil, err
+		}
+		return s.prRepo.GetByID(ctx, prID)
+	}
+
+	return nil, domain.ErrNotAssigned
+}
+
+// loadTeam returns the team settings. Users may reference a team that has no
+// row of its own, in that case the defaults are used.
+func (s *prService) loadTeam(ctx context.Context, teamName string) (*domain.Team, error) {
+	team, err := s.teamRepo.GetByName(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.Team{
+				TeamName:     teamName,
+				MinReviewers: domain.DefaultMinReviewers,
+				MaxReviewers: domain.DefaultMaxReviewers,
+			}, nil
+		}
+		return nil, err
+	}
+	return team, nil
+}
+
+// pickCodeOwners selects up to n owners of the files changed by pr according
+// to the code owners rules of team, one per owning rule in order of the files.
+// Picked users are added to exclude.
+func (s *prService) pickCodeOwners(ctx context.Context, team *domain.Team, pr domain.PullRequest, exclude map[string]bool, n int) ([]domain.Reviewer, error) {
+	if len(pr.FilePaths) == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	rules, err := s.teamRepo.GetCodeOwners(ctx, team.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		owning []domain.CodeOwnerRule
+		seen   = make(map[int64]bool)
+	)
+	for _, p := range pr.FilePaths {
+		rule, ok := domain.OwningRule(rules, p)
+		if !ok || seen[rule.ID] {
+			continue
+		}
+		seen[rule.ID] = true
+		owning = append(owning, rule)
+	}
+
+	var res []domain.Reviewer
+	for _, rule := range owning {
+		if len(res) >= n {
+			break
+		}
+
+		users, err := s.userRepo.GetByIDs(ctx, rule.Owners)
+		if err != nil {
+			return nil, err
+		}
+		picked, err := s.pickFromUsers(ctx, team, users, pr.Labels, exclude, 1)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range picked {
+			rv := s.newReviewer(id, team, domain.ViaCodeOwners)
+			rv.MatchedRule = rule.Pattern
+			res = append(res, rv)
+			exclude[id] = true
+		}
+	}
+	return res, nil
+}
+
+// pickRequiredRole tops reviewers up with members of team having the role
+// required by the team rule. When reviewers are already at the team maximum,
+// the last ones without the role give their place up.
+func (s *prService) pickRequiredRole(ctx context.Context, team *domain.Team, labels []string, reviewers []domain.Reviewer, exclude map[string]bool) ([]domain.Reviewer, error) {
+	if team.RequiredRoleCount <= 0 {
+		return reviewers, nil
+	}
+
+	ids := make([]string, 0, len(reviewers))
+	for _, rv := range reviewers {
+		ids = append(ids, rv.UserID)
+	}
+	users, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	qualified := make(map[string]bool, len(users))
+	for _, u := range users {
+		qualified[u.UserID] = u.Role == team.RequiredRole
+	}
+
+	need := team.RequiredRoleCount
+	for _, rv := range reviewers {
+		if qualified[rv.UserID] {
+			need--
+		}
+	}
+	if need <= 0 {
+		return reviewers, nil
+	}
+
+	members, err := s.membersWithRole(ctx, team.TeamName, team.RequiredRole)
+	if err != nil {
+		return nil, err
+	}
+	picked, err := s.pickFromUsers(ctx, team, members, labels, exclude, need)
+	if err != nil {
+		return nil, err
+	}
+	if len(picked) < need {
+		return nil, domain.ErrRoleRuleViolated
+	}
+
+	for i := len(reviewers) - 1; i >= 0 && len(reviewers)+len(picked) > team.MaxReviewers; i-- {
+		if !qualified[reviewers[i].UserID] {
+			reviewers = append(reviewers[:i], reviewers[i+1:]...)
+		}
+	}
+	for _, id := range picked {
+		reviewers = append(reviewers, s.newReviewer(id, team, domain.ViaRequiredRole))
+		exclude[id] = true
+	}
+	return reviewers, nil
+}
+
+// membersWithRole returns members of teamName having role.
+func (s *prService) membersWithRole(ctx context.Context, teamName, role string) ([]domain.User, error) {
+	users, err := s.userRepo.GetByTeamName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.User, 0, len(users))
+	for _, u := range users {
+		if u.Role == role {
+			res = append(res, u)
+		}
+	}
+	return res, nil
+}
+
+// pickFromTeam selects up to n reviewers among active and available members
+// of team that are not in exclude and still have review capacity, preferring
+// those whose tags match labels.
+func (s *prService) pickFromTeam(ctx context.Context, team *domain.Team, labels []string, exclude map[string]bool, n int) ([]string, error) {
+	users, err := s.userRepo.GetByTeamName(ctx, team.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	return s.pickFromUsers(ctx, team, users, labels, exclude, n)
+}
+
+// pickFromUsers selects up to n reviewers among eligible users, see
+// eligibleCandidates. Users sharing more tags with labels are picked first.
+func (s *prService) pickFromUsers(ctx context.Context, team *domain.Team, users []domain.User, labels []string, exclude map[string]bool, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	candidates, err := s.eligibleCandidates(ctx, team, users, exclude)
+	if err != nil {
+		return nil, err
+	}
+	return s.selectByTags(ctx, team, users, candidates, labels, n)
+}
+
+// eligibleCandidates returns ids of active and available users that are not
+// in exclude and still have review capacity under team limits, noting the
+// outcome for every user in the round decision.
+func (s *prService) eligibleCandidates(ctx context.Context, team *domain.Team, users []domain.User, exclude map[string]bool) ([]string, error) {
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.UserID)
+	}
+	away, err := s.unavailRepo.UnavailableAt(ctx, ids, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	eligible := make([]domain.User, 0, len(users))
+	for _, u := range users {
+		switch {
+		case u.UserID == s.decision.AuthorID:
+			s.decision.Note(u, domain.ExcludedAuthor)
+		case exclude[u.UserID]:
+			s.decision.Note(u, domain.ExcludedAssigned)
+		case !u.IsActive:
+			s.decision.Note(u, domain.ExcludedInactive)
+		case away[u.UserID]:
+			s.decision.Note(u, domain.ExcludedUnavailable)
+		default:
+			eligible = append(eligible, u)
+		}
+	}
+
+	candidates, err := s.withinCapacity(ctx, team, eligible)
+	if err != nil {
+		return nil, err
+	}
+
+	fits := make(map[string]bool, len(candidates))
+	for _, id := range candidates {
+		fits[id] = true
+	}
+	for _, u := range eligible {
+		if fits[u.UserID] {
+			s.decision.Note(u, "")
+		} else {
+			s.decision.Note(u, domain.ExcludedAtCapacity)
+		}
+	}
+	return candidates, nil
+}
+
+// selectByTags groups candidates by the number of their tags found in labels
+// and runs the team strategy on the best scored group first, moving on to
+// the next one while fewer than n reviewers are picked.
+func (s *prService) selectByTags(ctx context.Context, team *domain.Team, users []domain.User, candidates []string, labels []string, n int) ([]string, error) {
+	if len(labels) == 0 {
+		return s.selectReviewers(ctx, team, candidates, n)
+	}
+
+	scores := make(map[string]int, len(users))
+	for _, u := range users {
+		scores[u.UserID] = u.TagOverlap(labels)
+	}
+
+	tiers := make(map[int][]string)
+	var order []int
+	for _, id := range candidates {
+		score := scores[id]
+		if _, ok := tiers[score]; !ok {
+			order = append(order, score)
+		}
+		tiers[score] = append(tiers[score], id)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(order)))
+
+	var res []string
+	for _, score := range order {
+		if len(res) >= n {
+			break
+		}
+		picked, err := s.selectReviewers(ctx, team, tiers[score], n-len(res))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, picked...)
+	}
+	return res, nil
+}
+
+// pickFromFallbacks borrows up to n reviewers from the fallback teams of
+// teamName, trying them in order. Picked users are added to exclude.
+func (s *prService) pickFromFallbacks(ctx context.Context, teamName string, labels []string, exclude map[string]bool, n int) ([]domain.Reviewer, error) {
+	fallbacks, err := s.teamRepo.GetFallbacks(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []domain.Reviewer
+	for _, name := range fallbacks {
+		if len(res) >= n {
+			break
+		}
+
+		team, err := s.loadTeam(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		picked, err := s.pickFromTeam(ctx, team, labels, exclude, n-len(res))
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range picked {
+			rv := s.newReviewer(id, team, domain.ViaFallback)
+			rv.FallbackTeam = name
+			res = append(res, rv)
+			exclude[id] = true
+		}
+	}
+	return res, nil
+}
+
+// pickFromParents selects up to n reviewers from the closest ancestor of team
+// with eligible candidates, provided team may climb to its parent. Picked
+// users are added to exclude.
+func (s *prService) pickFromParents(ctx context.Context, team *domain.Team, labels []string, exclude map[string]bool, n int) ([]domain.Reviewer, error) {
+	if !team.ClimbToParent || n <= 0 {
+		return nil, nil
+	}
+
+	seen := map[string]bool{team.TeamName: true}
+	for name := team.ParentTeam; name != "" && !seen[name]; {
+		seen[name] = true
+
+		parent, err := s.loadTeam(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		picked, err := s.pickFromTeam(ctx, parent, labels, exclude, n)
+		if err != nil {
+			return nil, err
+		}
+		if len(picked) > 0 {
+			res := make([]domain.Reviewer, 0, len(picked))
+			for _, id := range picked {
+				rv := s.newReviewer(id, parent, domain.ViaParent)
+				rv.FallbackTeam = name
+				res = append(res, rv)
+				exclude[id] = true
+			}
+			return res, nil
+		}
+		name = parent.ParentTeam
+	}
+	return nil, nil
+}
+
+// withinCapacity drops users that already review as many OPEN pull requests
+// as their limit allows and returns ids of the remaining ones.
+func (s *prService) withinCapacity(ctx context.Context, team *domain.Team, users []domain.User) ([]string, error) {
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.UserID)
+	}
+
+	loads, err := s.prRepo.CountOpenReviews(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(users))
+	for _, u := range users {
+		limit := u.EffectiveMaxOpenReviews(team.DefaultMaxOpenReviews)
+		if limit > 0 && loads[u.UserID] >= int64(limit) {
+			continue
+		}
+		res = append(res, u.UserID)
+	}
+	return res, nil
+}
+
+// selectReviewers picks n reviewers from candidates using the strategy
+// configured for the team, or the global default when the team has none.
+func (s *prService) selectReviewers(ctx context.Context, team *domain.Team, candidates []string, n int) ([]string, error) {
+	return s.selectors.For(team.ReviewStrategy).Select(ctx, s.rnd, team.TeamName, candidates, n)
+}
+
+// newReviewer builds a reviewer picked from team, recording the strategy and
+// seed of the current assignment and how the user was picked.
+func (s *prService) newReviewer(userID string, team *domain.Team, via string) domain.Reviewer {
+	s.decision.Picked(userID, via)
+	return domain.Reviewer{
+		UserID:   userID,
+		Strategy: s.selectors.Resolve(team.ReviewStrategy),
+		Seed:     s.seed,
 	}
-	return res[:n]
 }
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Detsl735/avito-test/internal/domain"
 	"github.com/Detsl735/avito-test/internal/repository"
@@ -12,68 +14,1169 @@ import (
 	"gorm.io/gorm"
 )
 
+var allStrategies = []string{StrategyRandom, StrategyRoundRobin, StrategyLeastLoaded, StrategyWeighted}
+
 func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
+	require.NoError(t, repository.AutoMigrate(db))
+
+	return db
+}
+
+func newTestPRService(t *testing.T, db *gorm.DB, strategy string) (PRService, repository.UserRepository) {
+	t.Helper()
+	return newSeededPRService(t, db, strategy, time.Now().UnixNano())
+}
+
+func newSeededPRService(t *testing.T, db *gorm.DB, strategy string, seed int64) (PRService, repository.UserRepository) {
+	t.Helper()
+
+	userRepo := repository.NewUserRepository(db)
+	prRepo := repository.NewPRRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	unavailRepo := repository.NewUnavailabilityRepository(db)
 
-	err = db.AutoMigrate(&domain.Team{}, &domain.User{}, &domain.PullRequest{}, &domain.Reviewer{})
+	selectors, err := NewSelectorSet(strategy, prRepo.CountOpenReviews)
 	require.NoError(t, err)
 
-	return db
+	return NewPRService(db, prRepo, userRepo, teamRepo, unavailRepo, selectors, NewSeedSource(seed)), userRepo
+}
+
+var memberNames = []string{"Alice", "Bob", "Charlie", "Dave", "Eve"}
+
+// teamMembers returns n active users u1..un of teamName.
+func teamMembers(teamName string, n int) []domain.User {
+	users := make([]domain.User, n)
+	for i := range users {
+		users[i] = domain.User{
+			UserID:   fmt.Sprintf("u%d", i+1),
+			Username: memberNames[i],
+			TeamName: teamName,
+			IsActive: true,
+		}
+	}
+	return users
+}
+
+// setupTeamFixture creates a fresh database holding team and users and a
+// PRService that selects reviewers with strategy.
+func setupTeamFixture(t *testing.T, strategy string, team domain.Team, users []domain.User) (*gorm.DB, PRService, repository.UserRepository) {
+	t.Helper()
+
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, strategy)
+
+	require.NoError(t, db.Create(&team).Error)
+	require.NoError(t, userRepo.UpsertMany(context.Background(), users))
+
+	return db, prSvc, userRepo
+}
+
+// makeAdmin sets the admin flag the way operators do, directly in the database.
+func makeAdmin(t *testing.T, db *gorm.DB, userID string) {
+	t.Helper()
+	require.NoError(t, db.Model(&domain.User{}).Where("user_id = ?", userID).Update("is_admin", true).Error)
+}
+
+// forEachStrategy runs fn once per reviewer selection strategy.
+func forEachStrategy(t *testing.T, fn func(t *testing.T, strategy string)) {
+	for _, strategy := range allStrategies {
+		t.Run(strategy, func(t *testing.T) {
+			fn(t, strategy)
+		})
+	}
 }
 
 func TestCreatePR_AssignsReviewers(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		_, prSvc, _ := setupTeamFixture(t, strategy, domain.Team{TeamName: "backend"}, teamMembers("backend", 3))
+
+		ctx := context.Background()
+
+		pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+		require.NoError(t, err)
+		require.Equal(t, "pr-1", pr.PullRequestID)
+		require.Equal(t, domain.PRStatusOpen, pr.Status)
+		require.True(t, len(pr.AssignedReviewers) <= 2)
+		for _, r := range pr.AssignedReviewers {
+			require.NotEqual(t, "u1", r)
+		}
+	})
+}
+
+func TestMergePR_Idempotent(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		db := setupTestDB(t)
+		prSvc, _ := newTestPRService(t, db, strategy)
+
+		ctx := context.Background()
+
+		err := db.Create(&domain.PullRequest{
+			PullRequestID:   "pr-2",
+			PullRequestName: "Test merge",
+			AuthorID:        "u1",
+			Status:          domain.PRStatusOpen,
+		}).Error
+		require.NoError(t, err)
+
+		full, err := prSvc.MergePR(ctx, "pr-2")
+		require.NoError(t, err)
+		require.Equal(t, domain.PRStatusMerged, full.Status)
+		require.NotNil(t, full.MergedAt)
+
+		full2, err := prSvc.MergePR(ctx, "pr-2")
+		require.NoError(t, err)
+		require.Equal(t, domain.PRStatusMerged, full2.Status)
+	})
+}
+
+func TestReassignReviewer_PicksTeammate(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		_, prSvc, _ := setupTeamFixture(t, strategy, domain.Team{TeamName: "backend"}, teamMembers("backend", 4))
+
+		ctx := context.Background()
+
+		pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+		require.NoError(t, err)
+		require.Len(t, pr.AssignedReviewers, 2)
+
+		old := pr.AssignedReviewers[0]
+		full, newUserID, err := prSvc.ReassignReviewer(ctx, "pr-1", old)
+		require.NoError(t, err)
+		require.NotEqual(t, old, newUserID)
+		require.NotEqual(t, "u1", newUserID)
+		require.Contains(t, full.AssignedReviewers, newUserID)
+		require.NotContains(t, full.AssignedReviewers, old)
+	})
+}
+
+func TestCreatePR_UsesTeamStrategy(t *testing.T) {
 	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
 
-	userRepo := repository.NewUserRepository(db)
-	prRepo := repository.NewPRRepository(db)
-	prSvc := NewPRService(db, prRepo, userRepo)
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend", ReviewStrategy: StrategyRoundRobin}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+		{UserID: "u4", Username: "Dave", TeamName: "backend", IsActive: true},
+	}))
+
+	pr1, err := prSvc.CreatePR(ctx, "pr-1", "First", "u1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"u2", "u3"}, pr1.AssignedReviewers)
+
+	pr2, err := prSvc.CreatePR(ctx, "pr-2", "Second", "u1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"u4", "u2"}, pr2.AssignedReviewers)
+}
+
+func TestNewSelectorSet_UnknownStrategy(t *testing.T) {
+	_, err := NewSelectorSet("nope", nil)
+	require.ErrorIs(t, err, domain.ErrUnknownStrategy)
+
+	for _, strategy := range []string{StrategyRandom, StrategyRoundRobin, StrategyLeastLoaded, StrategyWeighted} {
+		require.True(t, IsKnownStrategy(strategy), strategy)
+	}
+	require.False(t, IsKnownStrategy("nope"))
+}
+
+func TestCreatePR_LeastLoadedPrefersIdleReviewers(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyLeastLoaded)
 
 	ctx := context.Background()
 
-	err := db.Create(&domain.Team{TeamName: "backend"}).Error
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend"}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+		{UserID: "u4", Username: "Dave", TeamName: "backend", IsActive: true},
+	}))
+
+	// u2 already reviews an open PR, u3 only a merged one
+	require.NoError(t, db.Create(&domain.PullRequest{PullRequestID: "open", PullRequestName: "o", AuthorID: "u4", Status: domain.PRStatusOpen}).Error)
+	require.NoError(t, db.Create(&domain.PullRequest{PullRequestID: "merged", PullRequestName: "m", AuthorID: "u4", Status: domain.PRStatusMerged}).Error)
+	require.NoError(t, db.Create(&domain.Reviewer{PullRequestID: "open", UserID: "u2"}).Error)
+	require.NoError(t, db.Create(&domain.Reviewer{PullRequestID: "merged", UserID: "u3"}).Error)
+
+	pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"u3", "u4"}, pr.AssignedReviewers)
+
+	full, newUserID, err := prSvc.ReassignReviewer(ctx, "pr-1", "u3")
 	require.NoError(t, err)
+	require.Equal(t, "u2", newUserID)
+	require.ElementsMatch(t, []string{"u2", "u4"}, full.AssignedReviewers)
+}
+
+func TestCreatePR_SkipsReviewersAtCapacity(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		one := 1
+		db, prSvc, _ := setupTeamFixture(t, strategy, domain.Team{TeamName: "backend", DefaultMaxOpenReviews: 2}, []domain.User{
+			{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+			{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true, MaxOpenReviews: &one},
+			{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+			{UserID: "u4", Username: "Dave", TeamName: "backend", IsActive: true},
+		})
+
+		ctx := context.Background()
+
+		require.NoError(t, db.Create(&domain.PullRequest{PullRequestID: "old-1", PullRequestName: "o", AuthorID: "u1", Status: domain.PRStatusOpen}).Error)
+		require.NoError(t, db.Create(&domain.PullRequest{PullRequestID: "old-2", PullRequestName: "o", AuthorID: "u1", Status: domain.PRStatusOpen}).Error)
+		require.NoError(t, db.Create(&domain.Reviewer{PullRequestID: "old-1", UserID: "u2"}).Error)
+		require.NoError(t, db.Create(&domain.Reviewer{PullRequestID: "old-1", UserID: "u3"}).Error)
+		require.NoError(t, db.Create(&domain.Reviewer{PullRequestID: "old-2", UserID: "u3"}).Error)
+
+		pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"u4"}, pr.AssignedReviewers)
+
+		_, _, err = prSvc.ReassignReviewer(ctx, "pr-1", "u4")
+		require.ErrorIs(t, err, domain.ErrNoCandidate)
+	})
+}
+
+func TestCreatePR_HonoursTeamReviewerCount(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		db := setupTestDB(t)
+		prSvc, userRepo := newTestPRService(t, db, strategy)
+
+		ctx := context.Background()
+
+		require.NoError(t, db.Create(&domain.Team{TeamName: "backend", MinReviewers: 3, MaxReviewers: 3}).Error)
+		require.NoError(t, db.Create(&domain.Team{TeamName: "frontend", MinReviewers: 2, MaxReviewers: 4}).Error)
+		require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+			{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+			{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+			{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+			{UserID: "u4", Username: "Dave", TeamName: "backend", IsActive: true},
+			{UserID: "f1", Username: "Eve", TeamName: "frontend", IsActive: true},
+			{UserID: "f2", Username: "Frank", TeamName: "frontend", IsActive: true},
+		}))
+
+		pr, err := prSvc.CreatePR(ctx, "pr-1", "Backend", "u1")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"u2", "u3", "u4"}, pr.AssignedReviewers)
+		require.Zero(t, pr.MissingReviewers)
+
+		pr, err = prSvc.CreatePR(ctx, "pr-2", "Frontend", "f1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"f2"}, pr.AssignedReviewers)
+		require.Equal(t, 1, pr.MissingReviewers)
+	})
+}
+
+func TestCreatePR_BorrowsFromFallbackTeams(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		db := setupTestDB(t)
+		prSvc, userRepo := newTestPRService(t, db, strategy)
+		teamRepo := repository.NewTeamRepository(db)
+
+		ctx := context.Background()
+
+		for _, name := range []string{"backend", "platform", "infra"} {
+			require.NoError(t, db.Create(&domain.Team{TeamName: name}).Error)
+		}
+		require.NoError(t, teamRepo.SetFallbacks(ctx, "backend", []string{"platform", "infra"}))
+		require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+			{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+			{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+			{UserID: "p1", Username: "Paul", TeamName: "platform", IsActive: true},
+			{UserID: "i1", Username: "Ivan", TeamName: "infra", IsActive: true},
+			{UserID: "i2", Username: "Inna", TeamName: "infra", IsActive: true},
+		}))
+		_, err := userRepo.SetIsActive(ctx, "p1", false)
+		require.NoError(t, err)
+
+		pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 2)
+		require.Equal(t, "u2", pr.Reviewers[0].UserID)
+		require.Empty(t, pr.Reviewers[0].FallbackTeam)
+		require.Contains(t, []string{"i1", "i2"}, pr.Reviewers[1].UserID)
+		require.Equal(t, "infra", pr.Reviewers[1].FallbackTeam)
+		require.Zero(t, pr.MissingReviewers)
+
+		full, newUserID, err := prSvc.ReassignReviewer(ctx, "pr-1", "u2")
+		require.NoError(t, err)
+		require.Contains(t, []string{"i1", "i2"}, newUserID)
+		require.NotContains(t, full.AssignedReviewers, "u2")
+		for _, rv := range full.Reviewers {
+			require.Equal(t, "infra", rv.FallbackTeam)
+		}
+	})
+}
+
+func TestCreatePR_PrefersCodeOwners(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		db, prSvc, _ := setupTeamFixture(t, strategy, domain.Team{TeamName: "backend", MinReviewers: 1, MaxReviewers: 3}, []domain.User{
+			{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+			{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+			{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+			{UserID: "u4", Username: "Dave", TeamName: "backend", IsActive: true},
+			{UserID: "d1", Username: "Dina", TeamName: "docs", IsActive: true},
+		})
+		teamRepo := repository.NewTeamRepository(db)
+
+		ctx := context.Background()
+
+		require.NoError(t, teamRepo.SetCodeOwners(ctx, "backend", []domain.CodeOwnerRule{
+			{Pattern: "*", Owners: []string{"u2"}},
+			{Pattern: "/internal/billing/", Owners: []string{"u3"}},
+			{Pattern: "*.md", Owners: []string{"d1"}},
+		}))
+
+		pr, err := prSvc.CreatePRWithOptions(ctx, "pr-1", "Test", "u1", domain.CreatePROptions{
+			FilePaths: []string{"internal/billing/invoice.go", "README.md"},
+		})
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 3)
 
-	users := []domain.User{
+		matched := make(map[string]string)
+		for _, rv := range pr.Reviewers {
+			matched[rv.UserID] = rv.MatchedRule
+		}
+		require.Equal(t, "/internal/billing/", matched["u3"])
+		require.Equal(t, "*.md", matched["d1"])
+		require.Len(t, matched, 3)
+		require.NotContains(t, matched, "u1")
+
+		stored, err := prSvc.CreateDraftPR(ctx, "pr-2", "Draft", "u1")
+		require.NoError(t, err)
+		require.Empty(t, stored.Reviewers)
+	})
+}
+
+func TestCreatePR_PrefersMatchingTags(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		_, prSvc, _ := setupTeamFixture(t, strategy, domain.Team{TeamName: "backend"}, []domain.User{
+			{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true, Tags: []string{"sql"}},
+			{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true, Tags: []string{"go"}},
+			{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true, Tags: []string{"go", "SQL"}},
+			{UserID: "u4", Username: "Dave", TeamName: "backend", IsActive: true, Tags: []string{"frontend"}},
+			{UserID: "u5", Username: "Eve", TeamName: "backend", IsActive: true},
+		})
+
+		ctx := context.Background()
+
+		for i := 0; i < 3; i++ {
+			pr, err := prSvc.CreatePRWithOptions(ctx, fmt.Sprintf("pr-%d", i), "Migration", "u1", domain.CreatePROptions{
+				Labels: []string{"go", "sql"},
+			})
+			require.NoError(t, err)
+			require.Equal(t, []string{"go", "sql"}, pr.Labels)
+			require.Len(t, pr.AssignedReviewers, 2)
+			// u3 matches both labels, u2 one; u1 is the author
+			require.ElementsMatch(t, []string{"u2", "u3"}, pr.AssignedReviewers)
+		}
+	})
+}
+
+func TestCreatePR_RequiresRole(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		_, prSvc, userRepo := setupTeamFixture(t, strategy, domain.Team{
+			TeamName:          "backend",
+			MinReviewers:      2,
+			MaxReviewers:      2,
+			RequiredRole:      "senior",
+			RequiredRoleCount: 1,
+		}, []domain.User{
+			{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+			{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+			{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+			{UserID: "u4", Username: "Dave", TeamName: "backend", IsActive: true},
+			{UserID: "s1", Username: "Sam", TeamName: "backend", IsActive: true, Role: "senior"},
+		})
+
+		ctx := context.Background()
+
+		pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+		require.NoError(t, err)
+		require.Len(t, pr.AssignedReviewers, 2)
+		require.Contains(t, pr.AssignedReviewers, "s1")
+
+		// s1 is the only senior, so there is nobody to take their place
+		_, _, err = prSvc.ReassignReviewer(ctx, "pr-1", "s1")
+		require.ErrorIs(t, err, domain.ErrRoleRuleViolated)
+
+		require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+			{UserID: "s2", Username: "Sara", TeamName: "backend", IsActive: true, Role: "senior"},
+		}))
+		full, newUserID, err := prSvc.ReassignReviewer(ctx, "pr-1", "s1")
+		require.NoError(t, err)
+		require.Equal(t, "s2", newUserID)
+		require.Contains(t, full.AssignedReviewers, "s2")
+
+		_, err = userRepo.SetIsActive(ctx, "s1", false)
+		require.NoError(t, err)
+		_, err = userRepo.SetIsActive(ctx, "s2", false)
+		require.NoError(t, err)
+		_, err = prSvc.CreatePR(ctx, "pr-2", "Test", "u1")
+		require.ErrorIs(t, err, domain.ErrRoleRuleViolated)
+	})
+}
+
+func TestCreatePR_SeedMakesSelectionReproducible(t *testing.T) {
+	for _, strategy := range []string{StrategyRandom, StrategyLeastLoaded, StrategyWeighted} {
+		t.Run(strategy, func(t *testing.T) {
+			ctx := context.Background()
+
+			run := func() [][]domain.Reviewer {
+				db := setupTestDB(t)
+				prSvc, userRepo := newSeededPRService(t, db, strategy, 42)
+
+				var users []domain.User
+				for i := 0; i < 8; i++ {
+					users = append(users, domain.User{
+						UserID: fmt.Sprintf("u%d", i), Username: "User", TeamName: "backend", IsActive: true,
+					})
+				}
+				require.NoError(t, userRepo.UpsertMany(ctx, users))
+
+				var res [][]domain.Reviewer
+				for i := 0; i < 5; i++ {
+					pr, err := prSvc.CreatePR(ctx, fmt.Sprintf("pr-%d", i), "Test", "u0")
+					require.NoError(t, err)
+					res = append(res, pr.Reviewers)
+				}
+				return res
+			}
+
+			first, second := run(), run()
+			require.Len(t, second, len(first))
+			for i := range first {
+				require.Len(t, first[i], 2)
+				seed := first[i][0].Seed
+				for j, rv := range first[i] {
+					require.Equal(t, strategy, rv.Strategy)
+					require.Equal(t, seed, rv.Seed)
+					require.Equal(t, rv.UserID, second[i][j].UserID)
+					require.Equal(t, rv.Seed, second[i][j].Seed)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmitReview_RecordsVerdict(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend"}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
 		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
 		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
 		{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+	}))
+
+	pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+	require.NoError(t, err)
+	for _, rv := range pr.Reviewers {
+		require.Equal(t, domain.ReviewStatePending, rv.State)
+		require.False(t, rv.AssignedAt.IsZero())
+		require.Nil(t, rv.ReviewedAt)
+	}
+
+	full, err := prSvc.SubmitReview(ctx, "pr-1", "u2", domain.ReviewStateApproved)
+	require.NoError(t, err)
+	for _, rv := range full.Reviewers {
+		if rv.UserID == "u2" {
+			require.Equal(t, domain.ReviewStateApproved, rv.State)
+			require.NotNil(t, rv.ReviewedAt)
+		} else {
+			require.Equal(t, domain.ReviewStatePending, rv.State)
+		}
 	}
-	require.NoError(t, userRepo.UpsertMany(ctx, users))
+
+	page, err := prSvc.GetReviewPRs(ctx, "u2", domain.ReviewFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.PullRequests, 1)
+	require.Equal(t, domain.ReviewStateApproved, page.PullRequests[0].ReviewState)
+
+	_, err = prSvc.SubmitReview(ctx, "pr-1", "u1", domain.ReviewStateApproved)
+	require.ErrorIs(t, err, domain.ErrNotAssigned)
+
+	_, err = prSvc.SubmitReview(ctx, "pr-1", "u2", domain.ReviewStatePending)
+	require.ErrorIs(t, err, domain.ErrInvalidReviewState)
+
+	_, err = prSvc.MergePR(ctx, "pr-1")
+	require.NoError(t, err)
+	_, err = prSvc.SubmitReview(ctx, "pr-1", "u3", domain.ReviewStateChangesRequested)
+	require.ErrorIs(t, err, domain.ErrPRMerged)
+}
+
+func TestMergePR_RequiresApprovals(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend", RequiredApprovals: 2}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+	}))
+
+	_, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+	require.NoError(t, err)
+
+	_, err = prSvc.SubmitReview(ctx, "pr-1", "u2", domain.ReviewStateApproved)
+	require.NoError(t, err)
+	_, err = prSvc.MergePR(ctx, "pr-1")
+	require.ErrorIs(t, err, domain.ErrMergeBlocked)
+
+	_, err = prSvc.SubmitReview(ctx, "pr-1", "u3", domain.ReviewStateChangesRequested)
+	require.NoError(t, err)
+	_, err = prSvc.MergePR(ctx, "pr-1")
+	require.ErrorIs(t, err, domain.ErrMergeBlocked)
+
+	_, err = prSvc.SubmitReview(ctx, "pr-1", "u3", domain.ReviewStateApproved)
+	require.NoError(t, err)
+	full, err := prSvc.MergePR(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, domain.PRStatusMerged, full.Status)
+	require.False(t, full.ForceMerged)
+}
+
+func TestForceMergePR_BypassesApprovals(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend", RequiredApprovals: 1}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		// neither the role nor the flag sent with the team grants admin
+		{UserID: "admin", Username: "Admin", TeamName: "infra", IsActive: true, Role: "admin", IsAdmin: true},
+	}))
+
+	_, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+	require.NoError(t, err)
+	_, err = prSvc.SubmitReview(ctx, "pr-1", "u2", domain.ReviewStateChangesRequested)
+	require.NoError(t, err)
+
+	_, err = prSvc.ForceMergePR(ctx, "pr-1", "nobody-at-all")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = prSvc.ForceMergePR(ctx, "pr-1", "u1")
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = prSvc.ForceMergePR(ctx, "pr-1", "admin")
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	full, err := prSvc.GetPR(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, domain.PRStatusOpen, full.Status)
+
+	makeAdmin(t, db, "admin")
+	full, err = prSvc.ForceMergePR(ctx, "pr-1", "admin")
+	require.NoError(t, err)
+	require.Equal(t, domain.PRStatusMerged, full.Status)
+	require.True(t, full.ForceMerged)
+	require.Equal(t, "admin", full.MergedBy)
+}
+
+func TestPRLifecycle_DraftReadyCloseReopen(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend"}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+	}))
+
+	pr, err := prSvc.CreateDraftPR(ctx, "pr-1", "Test", "u1")
+	require.NoError(t, err)
+	require.Equal(t, domain.PRStatusDraft, pr.Status)
+	require.Empty(t, pr.AssignedReviewers)
+
+	_, err = prSvc.MergePR(ctx, "pr-1")
+	require.ErrorIs(t, err, domain.ErrInvalidTransition)
+	_, err = prSvc.ReopenPR(ctx, "pr-1")
+	require.ErrorIs(t, err, domain.ErrInvalidTransition)
+
+	pr, err = prSvc.MarkReady(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, domain.PRStatusOpen, pr.Status)
+	require.ElementsMatch(t, []string{"u2", "u3"}, pr.AssignedReviewers)
+
+	_, err = prSvc.MarkReady(ctx, "pr-1")
+	require.ErrorIs(t, err, domain.ErrInvalidTransition)
+
+	pr, err = prSvc.ClosePR(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, domain.PRStatusClosed, pr.Status)
+	require.NotNil(t, pr.ClosedAt)
+	require.ElementsMatch(t, []string{"u2", "u3"}, pr.AssignedReviewers)
+
+	page, err := prSvc.GetReviewPRs(ctx, "u2", domain.ReviewFilter{Status: domain.PRStatusClosed})
+	require.NoError(t, err)
+	require.Len(t, page.PullRequests, 1)
+	require.Equal(t, "pr-1", page.PullRequests[0].PullRequestID)
+	require.Equal(t, domain.PRStatusClosed, page.PullRequests[0].Status)
+	page, err = prSvc.GetReviewPRs(ctx, "u2", domain.ReviewFilter{Status: domain.PRStatusOpen})
+	require.NoError(t, err)
+	require.Empty(t, page.PullRequests)
+
+	_, _, err = prSvc.ReassignReviewer(ctx, "pr-1", "u2")
+	require.ErrorIs(t, err, domain.ErrPRNotOpen)
+
+	pr, err = prSvc.ReopenPR(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, domain.PRStatusOpen, pr.Status)
+	require.Nil(t, pr.ClosedAt)
+	require.ElementsMatch(t, []string{"u2", "u3"}, pr.AssignedReviewers)
+
+	pr, err = prSvc.MergePR(ctx, "pr-1")
+	require.NoError(t, err)
+	_, err = prSvc.ClosePR(ctx, "pr-1")
+	require.ErrorIs(t, err, domain.ErrInvalidTransition)
+}
+
+func TestReopenPR_DropsInactiveReviewers(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend"}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, teamMembers("backend", 3)))
 
 	pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
 	require.NoError(t, err)
-	require.Equal(t, "pr-1", pr.PullRequestID)
+	require.ElementsMatch(t, []string{"u2", "u3"}, pr.AssignedReviewers)
+	_, err = prSvc.ClosePR(ctx, "pr-1")
+	require.NoError(t, err)
+
+	for _, id := range []string{"u2", "u3"} {
+		_, err = userRepo.SetIsActive(ctx, id, false)
+		require.NoError(t, err)
+	}
+
+	pr, err = prSvc.ReopenPR(ctx, "pr-1")
+	require.NoError(t, err)
 	require.Equal(t, domain.PRStatusOpen, pr.Status)
-	require.True(t, len(pr.AssignedReviewers) <= 2)
-	for _, r := range pr.AssignedReviewers {
-		require.NotEqual(t, "u1", r)
+	require.Empty(t, pr.AssignedReviewers)
+	require.Equal(t, 2, pr.MissingReviewers)
+}
+
+func TestReopenPR_ResetsVerdicts(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend", RequiredApprovals: 2}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, teamMembers("backend", 3)))
+
+	_, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+	require.NoError(t, err)
+	for _, id := range []string{"u2", "u3"} {
+		_, err = prSvc.SubmitReview(ctx, "pr-1", id, domain.ReviewStateApproved)
+		require.NoError(t, err)
+	}
+	_, err = prSvc.ClosePR(ctx, "pr-1")
+	require.NoError(t, err)
+
+	pr, err := prSvc.ReopenPR(ctx, "pr-1")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"u2", "u3"}, pr.AssignedReviewers)
+	for _, rv := range pr.Reviewers {
+		require.Equal(t, domain.ReviewStatePending, rv.State)
+		require.Nil(t, rv.ReviewedAt)
 	}
+
+	// approvals given before the pull request was closed do not count
+	_, err = prSvc.MergePR(ctx, "pr-1")
+	require.ErrorIs(t, err, domain.ErrMergeBlocked)
 }
 
-func TestMergePR_Idempotent(t *testing.T) {
+func TestCreatePR_SkipsUnavailableUsers(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		db, prSvc, _ := setupTeamFixture(t, strategy, domain.Team{TeamName: "backend"}, teamMembers("backend", 4))
+
+		ctx := context.Background()
+		now := time.Now().UTC()
+
+		require.NoError(t, db.Create(&[]domain.Unavailability{
+			{UserID: "u2", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Reason: "vacation"},
+			{UserID: "u3", StartsAt: now.Add(-48 * time.Hour), EndsAt: now.Add(-24 * time.Hour)},
+			{UserID: "u4", StartsAt: now.Add(24 * time.Hour), EndsAt: now.Add(48 * time.Hour)},
+		}).Error)
+
+		pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"u3", "u4"}, pr.AssignedReviewers)
+
+		_, _, err = prSvc.ReassignReviewer(ctx, "pr-1", "u3")
+		require.ErrorIs(t, err, domain.ErrNoCandidate)
+	})
+}
+
+func TestExplainAssignment(t *testing.T) {
 	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
 
-	userRepo := repository.NewUserRepository(db)
-	prRepo := repository.NewPRRepository(db)
-	prSvc := NewPRService(db, prRepo, userRepo)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	one := 1
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend", MinReviewers: 1, MaxReviewers: 2}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+		{UserID: "u4", Username: "Dave", TeamName: "backend", IsActive: true},
+		{UserID: "u5", Username: "Eve", TeamName: "backend", IsActive: true, MaxOpenReviews: &one},
+	}))
+	_, err := userRepo.SetIsActive(ctx, "u3", false)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&domain.Unavailability{UserID: "u4", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}).Error)
+	require.NoError(t, db.Create(&domain.PullRequest{PullRequestID: "pr-0", PullRequestName: "Busy", AuthorID: "u1", Status: domain.PRStatusOpen, CreatedAt: now}).Error)
+	require.NoError(t, db.Create(&domain.Reviewer{PullRequestID: "pr-0", UserID: "u5"}).Error)
+
+	_, err = prSvc.ExplainAssignment(ctx, "pr-0")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+	require.NoError(t, err)
+
+	d, err := prSvc.ExplainAssignment(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, domain.AssignmentCreate, d.Operation)
+	require.Equal(t, "u1", d.AuthorID)
+
+	byUser := make(map[string]domain.CandidateDecision)
+	for _, c := range d.Candidates {
+		byUser[c.UserID] = c
+	}
+	require.Len(t, byUser, 5)
+	require.Equal(t, domain.ExcludedAuthor, byUser["u1"].Excluded)
+	require.Equal(t, domain.ExcludedInactive, byUser["u3"].Excluded)
+	require.Equal(t, domain.ExcludedUnavailable, byUser["u4"].Excluded)
+	require.Equal(t, domain.ExcludedAtCapacity, byUser["u5"].Excluded)
+	require.True(t, byUser["u2"].Chosen)
+	require.Equal(t, domain.ViaTeam, byUser["u2"].Via)
+	require.Equal(t, StrategyRandom, byUser["u2"].Strategy)
+
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u6", Username: "Frank", TeamName: "backend", IsActive: true},
+	}))
+	_, newUserID, err := prSvc.ReassignReviewer(ctx, "pr-1", "u2")
+	require.NoError(t, err)
+	require.Equal(t, "u6", newUserID)
+
+	d, err = prSvc.ExplainAssignment(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, domain.AssignmentReassign, d.Operation)
+	require.Equal(t, "u2", d.ReplacedUserID)
+	for _, c := range d.Candidates {
+		switch c.UserID {
+		case "u2":
+			require.Equal(t, domain.ExcludedAssigned, c.Excluded)
+		case "u6":
+			require.True(t, c.Chosen)
+		default:
+			require.False(t, c.Chosen)
+		}
+	}
+}
+
+func TestAddRemoveReviewer(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+	teamRepo := repository.NewTeamRepository(db)
 
 	ctx := context.Background()
 
-	err := db.Create(&domain.PullRequest{
-		PullRequestID:   "pr-2",
-		PullRequestName: "Test merge",
-		AuthorID:        "u1",
-		Status:          domain.PRStatusOpen,
-	}).Error
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend", MinReviewers: 1, MaxReviewers: 2}).Error)
+	for _, name := range []string{"platform", "infra"} {
+		require.NoError(t, db.Create(&domain.Team{TeamName: name, MinReviewers: 1, MaxReviewers: 1}).Error)
+	}
+	require.NoError(t, teamRepo.SetFallbacks(ctx, "backend", []string{"platform"}))
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+		{UserID: "p1", Username: "Paul", TeamName: "platform", IsActive: true},
+		{UserID: "p2", Username: "Pete", TeamName: "platform", IsActive: true},
+		{UserID: "p3", Username: "Phil", TeamName: "platform", IsActive: true},
+		{UserID: "i1", Username: "Ivan", TeamName: "infra", IsActive: true},
+		{UserID: "a1", Username: "Admin", TeamName: "infra", IsActive: true},
+	}))
+	_, err := userRepo.SetIsActive(ctx, "u3", false)
+	require.NoError(t, err)
+	makeAdmin(t, db, "a1")
+	now := time.Now().UTC()
+	require.NoError(t, db.Create(&domain.Unavailability{UserID: "p2", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}).Error)
+	limit := 1
+	_, err = userRepo.SetMaxOpenReviews(ctx, "p3", &limit)
+	require.NoError(t, err)
+	// p3 gets the only review of a platform PR and reaches the limit
+	pr, err := prSvc.CreatePR(ctx, "pr-0", "Platform", "p1")
 	require.NoError(t, err)
+	require.Equal(t, []string{"p3"}, pr.AssignedReviewers)
 
-	full, err := prSvc.MergePR(ctx, "pr-2")
+	pr, err = prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
 	require.NoError(t, err)
-	require.Equal(t, domain.PRStatusMerged, full.Status)
-	require.NotNil(t, full.MergedAt)
+	require.Equal(t, []string{"u2"}, pr.AssignedReviewers)
+
+	_, err = prSvc.AddReviewer(ctx, "pr-1", "u2", "p1")
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = prSvc.AddReviewer(ctx, "pr-1", "u1", "u2")
+	require.ErrorIs(t, err, domain.ErrAlreadyAssigned)
+	for _, id := range []string{"u1", "u3", "i1", "p2", "p3"} {
+		_, err = prSvc.AddReviewer(ctx, "pr-1", "u1", id)
+		require.ErrorIs(t, err, domain.ErrIneligibleReviewer, id)
+	}
+
+	full, err := prSvc.AddReviewer(ctx, "pr-1", "u1", "p1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"u2", "p1"}, full.AssignedReviewers)
+	require.Equal(t, "platform", full.Reviewers[1].FallbackTeam)
+
+	require.NoError(t, db.Delete(&domain.Unavailability{}, "user_id = ?", "p2").Error)
+	_, err = prSvc.AddReviewer(ctx, "pr-1", "u1", "p2")
+	require.ErrorIs(t, err, domain.ErrTooManyReviewers)
+
+	_, err = prSvc.RemoveReviewer(ctx, "pr-1", "a1", "i1")
+	require.ErrorIs(t, err, domain.ErrNotAssigned)
+	full, err = prSvc.RemoveReviewer(ctx, "pr-1", "a1", "u2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"p1"}, full.AssignedReviewers)
+
+	_, err = prSvc.ClosePR(ctx, "pr-1")
+	require.NoError(t, err)
+	_, err = prSvc.AddReviewer(ctx, "pr-1", "u1", "u2")
+	require.ErrorIs(t, err, domain.ErrPRNotOpen)
+
+	require.NoError(t, db.Create(&domain.Team{
+		TeamName:          "core",
+		MinReviewers:      2,
+		MaxReviewers:      2,
+		RequiredRole:      "senior",
+		RequiredRoleCount: 1,
+	}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "c1", Username: "Carl", TeamName: "core", IsActive: true},
+		{UserID: "c2", Username: "Cora", TeamName: "core", IsActive: true, Role: "senior"},
+		{UserID: "c3", Username: "Cole", TeamName: "core", IsActive: true},
+	}))
+	pr, err = prSvc.CreatePR(ctx, "pr-2", "Core", "c1")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"c2", "c3"}, pr.AssignedReviewers)
+
+	_, err = prSvc.RemoveReviewer(ctx, "pr-2", "c1", "c2")
+	require.ErrorIs(t, err, domain.ErrRoleRuleViolated)
+	full, err = prSvc.RemoveReviewer(ctx, "pr-2", "c1", "c3")
+	require.NoError(t, err)
+	require.Equal(t, []string{"c2"}, full.AssignedReviewers)
+}
+
+func TestReassignReviewerTo(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+	teamRepo := repository.NewTeamRepository(db)
+
+	ctx := context.Background()
+
+	for _, name := range []string{"backend", "platform", "infra"} {
+		require.NoError(t, db.Create(&domain.Team{TeamName: name, MinReviewers: 1, MaxReviewers: 1}).Error)
+	}
+	require.NoError(t, teamRepo.SetFallbacks(ctx, "backend", []string{"platform"}))
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		{UserID: "p1", Username: "Paul", TeamName: "platform", IsActive: true},
+		{UserID: "i1", Username: "Ivan", TeamName: "infra", IsActive: true},
+	}))
+
+	pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"u2"}, pr.AssignedReviewers)
+
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+		{UserID: "u4", Username: "Dave", TeamName: "backend", IsActive: true},
+	}))
+	_, err = userRepo.SetIsActive(ctx, "u4", false)
+	require.NoError(t, err)
+
+	for _, id := range []string{"u1", "u2", "u4", "i1"} {
+		_, err = prSvc.ReassignReviewerTo(ctx, "pr-1", "u2", id)
+		require.ErrorIs(t, err, domain.ErrIneligibleReviewer, id)
+	}
+	_, err = prSvc.ReassignReviewerTo(ctx, "pr-1", "u2", "no-such-user")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	full, err := prSvc.ReassignReviewerTo(ctx, "pr-1", "u2", "u3")
+	require.NoError(t, err)
+	require.Equal(t, []string{"u3"}, full.AssignedReviewers)
+
+	full, err = prSvc.ReassignReviewerTo(ctx, "pr-1", "u3", "p1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"p1"}, full.AssignedReviewers)
+	require.Equal(t, "platform", full.Reviewers[0].FallbackTeam)
+
+	d, err := prSvc.ExplainAssignment(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Len(t, d.Candidates, 1)
+	require.True(t, d.Candidates[0].Chosen)
+	require.Equal(t, domain.ViaRequested, d.Candidates[0].Via)
+}
+
+func TestListPRs(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		{UserID: "f1", Username: "Fred", TeamName: "frontend", IsActive: true},
+	}))
+
+	merged := base.Add(10 * time.Hour)
+	prs := []domain.PullRequest{
+		{PullRequestID: "pr-1", PullRequestName: "Add billing", AuthorID: "u1", Status: domain.PRStatusOpen, CreatedAt: base},
+		{PullRequestID: "pr-2", PullRequestName: "Fix 100% CPU", AuthorID: "u2", Status: domain.PRStatusMerged, CreatedAt: base.Add(time.Hour), MergedAt: &merged},
+		{PullRequestID: "pr-3", PullRequestName: "Billing UI", AuthorID: "f1", Status: domain.PRStatusOpen, CreatedAt: base.Add(2 * time.Hour)},
+		{PullRequestID: "pr-4", PullRequestName: "Refactor", AuthorID: "u1", Status: domain.PRStatusDraft, CreatedAt: base.Add(3 * time.Hour)},
+		{PullRequestID: "pr-5", PullRequestName: "Docs", AuthorID: "u2", Status: domain.PRStatusOpen, CreatedAt: base.Add(3 * time.Hour)},
+	}
+	require.NoError(t, db.Create(&prs).Error)
+	require.NoError(t, db.Create(&[]domain.Reviewer{
+		{PullRequestID: "pr-1", UserID: "u2"},
+		{PullRequestID: "pr-3", UserID: "u2"},
+		{PullRequestID: "pr-5", UserID: "u1"},
+	}).Error)
+
+	ids := func(page *domain.PRPage) []string {
+		var res []string
+		for _, pr := range page.PullRequests {
+			res = append(res, pr.PullRequestID)
+		}
+		return res
+	}
+	list := func(f domain.PRFilter) []string {
+		page, err := prSvc.ListPRs(ctx, f)
+		require.NoError(t, err)
+		return ids(page)
+	}
+
+	require.Equal(t, []string{"pr-1", "pr-2", "pr-3", "pr-4", "pr-5"}, list(domain.PRFilter{}))
+	require.Equal(t, []string{"pr-1", "pr-3", "pr-5"}, list(domain.PRFilter{Status: domain.PRStatusOpen}))
+	require.Equal(t, []string{"pr-1", "pr-4"}, list(domain.PRFilter{AuthorID: "u1"}))
+	require.Equal(t, []string{"pr-1", "pr-3"}, list(domain.PRFilter{ReviewerID: "u2"}))
+	require.Equal(t, []string{"pr-3"}, list(domain.PRFilter{TeamName: "frontend"}))
+	require.Equal(t, []string{"pr-1", "pr-3"}, list(domain.PRFilter{NameContains: "BILLING"}))
+	require.Equal(t, []string{"pr-2"}, list(domain.PRFilter{NameContains: "100%"}))
+
+	from, to := base.Add(time.Hour), base.Add(3*time.Hour)
+	require.Equal(t, []string{"pr-2", "pr-3"}, list(domain.PRFilter{CreatedFrom: &from, CreatedTo: &to}))
+	require.Equal(t, []string{"pr-2"}, list(domain.PRFilter{MergedFrom: &from}))
+	require.Equal(t, []string{"pr-1", "pr-3", "pr-5", "pr-2", "pr-4"}, list(domain.PRFilter{Sort: domain.PRSortName}))
+
+	// walk all pages backwards by creation time
+	var got []string
+	f := domain.PRFilter{Desc: true, Limit: 2}
+	for {
+		page, err := prSvc.ListPRs(ctx, f)
+		require.NoError(t, err)
+		got = append(got, ids(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		f.Cursor = page.NextCursor
+	}
+	require.Equal(t, []string{"pr-5", "pr-4", "pr-3", "pr-2", "pr-1"}, got)
+
+	page, err := prSvc.ListPRs(ctx, domain.PRFilter{Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, []string{"u2"}, page.PullRequests[0].AssignedReviewers)
+
+	for _, bad := range []domain.PRFilter{{Limit: 1000}, {Status: "NOPE"}, {Sort: "author_id"}, {Cursor: "%%%"}} {
+		_, err = prSvc.ListPRs(ctx, bad)
+		require.ErrorIs(t, err, domain.ErrInvalidFilter)
+	}
+}
+
+func TestGetPR(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+	}))
+	_, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+	require.NoError(t, err)
+	_, err = prSvc.SubmitReview(ctx, "pr-1", "u2", domain.ReviewStateApproved)
+	require.NoError(t, err)
+
+	full, err := prSvc.GetPR(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, "Test", full.PullRequestName)
+	require.NotNil(t, full.Author)
+	require.Equal(t, "backend", full.Author.TeamName)
+	require.Len(t, full.Reviewers, 1)
+	require.Equal(t, domain.ReviewStateApproved, full.Reviewers[0].State)
+	require.NotNil(t, full.Reviewers[0].ReviewedAt)
+
+	_, err = prSvc.GetPR(ctx, "no-such-pr")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestGetReviewPRs_FiltersAndPaginates(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	merged := base.Add(24 * time.Hour)
+
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+	}))
+	require.NoError(t, db.Create(&[]domain.PullRequest{
+		{PullRequestID: "pr-3", PullRequestName: "C", AuthorID: "u1", Status: domain.PRStatusOpen, CreatedAt: base.Add(2 * time.Hour)},
+		{PullRequestID: "pr-1", PullRequestName: "A", AuthorID: "u1", Status: domain.PRStatusOpen, CreatedAt: base},
+		{PullRequestID: "pr-2", PullRequestName: "B", AuthorID: "u1", Status: domain.PRStatusMerged, CreatedAt: base.Add(time.Hour), MergedAt: &merged},
+		{PullRequestID: "pr-4", PullRequestName: "D", AuthorID: "u1", Status: domain.PRStatusOpen, CreatedAt: base.Add(3 * time.Hour)},
+	}).Error)
+	for _, id := range []string{"pr-1", "pr-2", "pr-3", "pr-4"} {
+		require.NoError(t, db.Create(&domain.Reviewer{PullRequestID: id, UserID: "u2"}).Error)
+	}
+
+	page, err := prSvc.GetReviewPRs(ctx, "u2", domain.ReviewFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.PullRequests, 4)
+	require.Empty(t, page.NextCursor)
+	require.Equal(t, "pr-1", page.PullRequests[0].PullRequestID)
+	require.True(t, page.PullRequests[0].CreatedAt.Equal(base))
+	require.NotNil(t, page.PullRequests[1].MergedAt)
+
+	var got []string
+	f := domain.ReviewFilter{Status: domain.PRStatusOpen, Limit: 2}
+	for {
+		page, err := prSvc.GetReviewPRs(ctx, "u2", f)
+		require.NoError(t, err)
+		for _, pr := range page.PullRequests {
+			got = append(got, pr.PullRequestID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		f.Cursor = page.NextCursor
+	}
+	require.Equal(t, []string{"pr-1", "pr-3", "pr-4"}, got)
+
+	_, err = prSvc.GetReviewPRs(ctx, "u2", domain.ReviewFilter{Status: "NOPE"})
+	require.ErrorIs(t, err, domain.ErrInvalidFilter)
+}
+
+func TestCreatePR_UsesAllMemberships(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+
+	for _, name := range []string{"backend", "platform"} {
+		require.NoError(t, db.Create(&domain.Team{TeamName: name, MinReviewers: 1, MaxReviewers: 1}).Error)
+	}
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "p1", Username: "Paul", TeamName: "platform", IsActive: true},
+		{UserID: "p2", Username: "Pete", TeamName: "platform", IsActive: true},
+		// p1 joins backend as a second team
+		{UserID: "p1", Username: "Paul", TeamName: "backend", IsActive: true},
+	}))
+
+	p1, err := userRepo.GetByID(ctx, "p1")
+	require.NoError(t, err)
+	require.Equal(t, "platform", p1.TeamName)
+	require.Equal(t, []string{"platform", "backend"}, p1.Teams)
+
+	pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"p1"}, pr.AssignedReviewers)
+	require.Empty(t, pr.Reviewers[0].FallbackTeam)
+
+	// p1 was assigned for backend, so p2 of the primary team cannot replace them
+	_, _, err = prSvc.ReassignReviewer(ctx, "pr-1", "p1")
+	require.ErrorIs(t, err, domain.ErrNoCandidate)
+	_, err = prSvc.ReassignReviewerTo(ctx, "pr-1", "p1", "p2")
+	require.ErrorIs(t, err, domain.ErrIneligibleReviewer)
+
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "p2", Username: "Pete", TeamName: "backend", IsActive: true},
+	}))
+	full, newUserID, err := prSvc.ReassignReviewer(ctx, "pr-1", "p1")
+	require.NoError(t, err)
+	require.Equal(t, "p2", newUserID)
+	require.Equal(t, []string{"p2"}, full.AssignedReviewers)
+
+	pr, err = prSvc.CreatePR(ctx, "pr-2", "Test", "p1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"p2"}, pr.AssignedReviewers)
+}
+
+func TestCreatePR_ClimbsToParentTeam(t *testing.T) {
+	db := setupTestDB(t)
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "payments", MinReviewers: 1, MaxReviewers: 1}).Error)
+	require.NoError(t, db.Create(&domain.Team{
+		TeamName:     "payments/backend",
+		MinReviewers: 1,
+		MaxReviewers: 1,
+		ParentTeam:   "payments",
+	}).Error)
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "b1", Username: "Bob", TeamName: "payments/backend", IsActive: true},
+		{UserID: "p1", Username: "Paul", TeamName: "payments", IsActive: true},
+	}))
+
+	pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "b1")
+	require.NoError(t, err)
+	require.Empty(t, pr.AssignedReviewers)
+
+	require.NoError(t, db.Model(&domain.Team{}).Where("team_name = ?", "payments/backend").
+		Update("climb_to_parent", true).Error)
+
+	pr, err = prSvc.CreatePR(ctx, "pr-2", "Test", "b1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"p1"}, pr.AssignedReviewers)
+	require.Equal(t, "payments", pr.Reviewers[0].FallbackTeam)
+
+	decision, err := prSvc.ExplainAssignment(ctx, "pr-2")
+	require.NoError(t, err)
+	require.Equal(t, domain.ViaParent, decision.Candidates[len(decision.Candidates)-1].Via)
 
-	full2, err := prSvc.MergePR(ctx, "pr-2")
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "p2", Username: "Pete", TeamName: "payments", IsActive: true},
+	}))
+	_, newUserID, err := prSvc.ReassignReviewer(ctx, "pr-2", "p1")
 	require.NoError(t, err)
-	require.Equal(t, domain.PRStatusMerged, full2.Status)
+	require.Equal(t, "p2", newUserID)
 }
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/Detsl735/avito-test/internal/domain"
+)
+
+const (
+	StrategyRandom      = "random"
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastLoaded = "least_loaded"
+	StrategyWeighted    = "weighted"
+)
+
+// ReviewerSelector picks up to n reviewers out of already filtered candidates.
+// pool identifies the group the candidates come from (usually a team name)
+// and lets stateful strategies keep separate state per group. Randomized
+// strategies draw only from rnd, so the same seed gives the same picks.
+type ReviewerSelector interface {
+	Select(ctx context.Context, rnd *rand.Rand, pool string, candidates []string, n int) ([]string, error)
+}
+
+// ReviewLoadFunc returns the number of OPEN pull requests each of userIDs
+// is currently reviewing. Users without open reviews may be absent from the map.
+type ReviewLoadFunc func(ctx context.Context, userIDs []string) (map[string]int64, error)
+
+// SelectorSet holds one selector per strategy and the globally configured default.
+type SelectorSet struct {
+	defaultStrategy string
+	selectors       map[string]ReviewerSelector
+}
+
+// strategies builds the selector of every known strategy.
+var strategies = map[string]func(load ReviewLoadFunc) ReviewerSelector{
+	StrategyRandom:      func(ReviewLoadFunc) ReviewerSelector { return &randomSelector{} },
+	StrategyRoundRobin:  func(ReviewLoadFunc) ReviewerSelector { return newRoundRobinSelector() },
+	StrategyLeastLoaded: func(load ReviewLoadFunc) ReviewerSelector { return &leastLoadedSelector{load: load} },
+	StrategyWeighted:    func(load ReviewLoadFunc) ReviewerSelector { return &weightedSelector{load: load} },
+}
+
+func NewSelectorSet(defaultStrategy string, load ReviewLoadFunc) (*SelectorSet, error) {
+	if !IsKnownStrategy(defaultStrategy) {
+		return nil, fmt.Errorf("%w %q", domain.ErrUnknownStrategy, defaultStrategy)
+	}
+
+	set := &SelectorSet{
+		defaultStrategy: defaultStrategy,
+		selectors:       make(map[string]ReviewerSelector, len(strategies)),
+	}
+	for name, newSelector := range strategies {
+		set.selectors[name] = newSelector(load)
+	}
+	return set, nil
+}
+
+// WithLoad returns a set whose load based selectors count reviews with load,
+// e.g. inside a transaction. Round robin cursors are shared with s.
+func (s *SelectorSet) WithLoad(load ReviewLoadFunc) *SelectorSet {
+	set := &SelectorSet{
+		defaultStrategy: s.defaultStrategy,
+		selectors:       make(map[string]ReviewerSelector, len(s.selectors)),
+	}
+	for name, selector := range s.selectors {
+		if name == StrategyRoundRobin {
+			set.selectors[name] = selector
+			continue
+		}
+		set.selectors[name] = strategies[name](load)
+	}
+	return set
+}
+
+// For returns the selector for strategy, falling back to the default one
+// when strategy is empty or unknown.
+func (s *SelectorSet) For(strategy string) ReviewerSelector {
+	return s.selectors[s.Resolve(strategy)]
+}
+
+// Resolve returns the name of the strategy For would use.
+func (s *SelectorSet) Resolve(strategy string) string {
+	if _, ok := s.selectors[strategy]; ok {
+		return strategy
+	}
+	return s.defaultStrategy
+}
+
+// SeedSource hands out seeds for individual assignments. It is safe for
+// concurrent use; created with a fixed seed it yields the same sequence.
+type SeedSource struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func NewSeedSource(seed int64) *SeedSource {
+	return &SeedSource{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// Next returns the seed for the next assignment.
+func (s *SeedSource) Next() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rnd.Int63()
+}
+
+func IsKnownStrategy(strategy string) bool {
+	_, ok := strategies[strategy]
+	return ok
+}
+
+type randomSelector struct{}
+
+func (randomSelector) Select(_ context.Context, rnd *rand.Rand, _ string, candidates []string, n int) ([]string, error) {
+	return pickRandom(rnd, candidates, n), nil
+}
+
+type roundRobinSelector struct {
+	mu      sync.Mutex
+	cursors map[string]int
+}
+
+func newRoundRobinSelector() *roundRobinSelector {
+	return &roundRobinSelector{cursors: make(map[string]int)}
+}
+
+func (s *roundRobinSelector) Select(_ context.Context, _ *rand.Rand, pool string, candidates []string, n int) ([]string, error) {
+	if len(candidates) == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	sorted := make([]string, len(candidates))
+	copy(sorted, candidates)
+	sort.Strings(sorted)
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := s.cursors[pool] % len(sorted)
+	res := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		res = append(res, sorted[(start+i)%len(sorted)])
+	}
+	s.cursors[pool] = (start + n) % len(sorted)
+	return res, nil
+}
+
+type leastLoadedSelector struct {
+	load ReviewLoadFunc
+}
+
+func (s *leastLoadedSelector) Select(ctx context.Context, rnd *rand.Rand, _ string, candidates []string, n int) ([]string, error) {
+	if len(candidates) == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	loads, err := s.load(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	// shuffle first so that the stable sort breaks ties randomly
+	sorted := pickRandom(rnd, candidates, len(candidates))
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return loads[sorted[i]] < loads[sorted[j]]
+	})
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	return sorted[:n], nil
+}
+
+// weightedSelector draws reviewers at random with probability inversely
+// proportional to their current load, so busy users are picked less often.
+type weightedSelector struct {
+	load ReviewLoadFunc
+}
+
+func (s *weightedSelector) Select(ctx context.Context, rnd *rand.Rand, _ string, candidates []string, n int) ([]string, error) {
+	if len(candidates) == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	loads, err := s.load(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := make([]string, len(candidates))
+	copy(rest, candidates)
+
+	res := make([]string, 0, n)
+	for len(res) < n && len(rest) > 0 {
+		total := 0.0
+		for _, id := range rest {
+			total += 1 / float64(loads[id]+1)
+		}
+		x := rnd.Float64() * total
+		idx := len(rest) - 1
+		for i, id := range rest {
+			x -= 1 / float64(loads[id]+1)
+			if x < 0 {
+				idx = i
+				break
+			}
+		}
+		res = append(res, rest[idx])
+		rest = append(rest[:idx], rest[idx+1:]...)
+	}
+	return res, nil
+}
+
+func pickRandom(rnd *rand.Rand, items []string, n int) []string {
+	if len(items) == 0 || n <= 0 {
+		return nil
+	}
+	if len(items) < n {
+		n = len(items)
+	}
+	res := make([]string, len(items))
+	copy(res, items)
+	for i := range res {
+		j := rnd.Intn(i + 1)
+		res[i], res[j] = res[j], res[i]
+	}
+	return res[:n]
+}
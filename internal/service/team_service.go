@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/Detsl735/avito-test/internal/domain"
 	"github.com/Detsl735/avito-test/internal/repository"
@@ -11,19 +14,43 @@ import (
 type TeamService interface {
 	AddTeam(ctx context.Context, teamName string, members []domain.TeamMember) (*domain.Team, []domain.User, error)
 	GetTeam(ctx context.Context, teamName string) (*domain.Team, []domain.User, error)
+	GetSettings(ctx context.Context, teamName string) (*domain.Team, error)
+	UpdateSettings(ctx context.Context, teamName string, settings domain.TeamSettings) (*domain.Team, error)
+	GetFallbacks(ctx context.Context, teamName string) ([]string, error)
+	SetFallbacks(ctx context.Context, teamName string, fallbacks []string) ([]string, error)
+	GetCodeOwners(ctx context.Context, teamName string) ([]domain.CodeOwnerRule, error)
+	SetCodeOwners(ctx context.Context, teamName string, rules []domain.CodeOwnerRule) ([]domain.CodeOwnerRule, error)
+	AddMembers(ctx context.Context, teamName string, members []domain.TeamMember) ([]domain.User, error)
+	RemoveMembers(ctx context.Context, teamName string, userIDs []string, reassign bool) (*domain.MembershipReport, error)
+	MoveMember(ctx context.Context, userID, toTeam string, reassign bool) (*domain.MembershipReport, error)
+	RenameTeam(ctx context.Context, oldName, newName string) (*domain.Team, error)
+	DeleteTeam(ctx context.Context, teamName string, opts domain.TeamDeleteOptions) (*domain.TeamDeletionReport, error)
+	Tree(ctx context.Context, root string) ([]domain.TeamNode, error)
+	ListTeams(ctx context.Context, filter domain.TeamFilter) (*domain.TeamPage, error)
+	Availability(ctx context.Context, users []domain.User) (map[string]bool, error)
 }
 
 type teamService struct {
-	teamRepo repository.TeamRepository
-	userRepo repository.UserRepository
-	db       *gorm.DB
+	teamRepo    repository.TeamRepository
+	userRepo    repository.UserRepository
+	unavailRepo repository.UnavailabilityRepository
+	prService   PRService
+	db          *gorm.DB
 }
 
-func NewTeamService(db *gorm.DB, tRepo repository.TeamRepository, uRepo repository.UserRepository) TeamService {
+func NewTeamService(
+	db *gorm.DB,
+	tRepo repository.TeamRepository,
+	uRepo repository.UserRepository,
+	unavailRepo repository.UnavailabilityRepository,
+	prService PRService,
+) TeamService {
 	return &teamService{
-		teamRepo: tRepo,
-		userRepo: uRepo,
-		db:       db,
+		teamRepo:    tRepo,
+		userRepo:    uRepo,
+		unavailRepo: unavailRepo,
+		prService:   prService,
+		db:          db,
 	}
 }
 
@@ -32,10 +59,284 @@ func (s *teamService) AddTeam(ctx context.Context, teamName string, members []do
 		return nil, nil, domain.ErrTeamExists
 	}
 
-	if err := s.db.WithContext(ctx).Create(&domain.Team{TeamName: teamName}).Error; err != nil {
+	team := domain.Team{
+		TeamName:     teamName,
+		MinReviewers: domain.DefaultMinReviewers,
+		MaxReviewers: domain.DefaultMaxReviewers,
+	}
+	if err := s.db.WithContext(ctx).Create(&team).Error; err != nil {
+		return nil, nil, err
+	}
+
+	users := membersToUsers(teamName, members)
+	if err := s.userRepo.UpsertMany(ctx, users); err != nil {
 		return nil, nil, err
 	}
 
+	return &team, users, nil
+}
+
+// AddMembers adds members to an existing team or updates them if they are
+// already in it. Members of other teams stay there, their primary team is
+// not changed.
+func (s *teamService) AddMembers(ctx context.Context, teamName string, members []domain.TeamMember) ([]domain.User, error) {
+	if _, err := s.GetSettings(ctx, teamName); err != nil {
+		return nil, err
+	}
+
+	users := membersToUsers(teamName, members)
+	if err := s.userRepo.UpsertMany(ctx, users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// RemoveMembers takes userIDs out of teamName, their other teams are kept.
+// With reassign their OPEN reviews go to remaining members of teamName,
+// otherwise they keep them.
+func (s *teamService) RemoveMembers(ctx context.Context, teamName string, userIDs []string, reassign bool) (*domain.MembershipReport, error) {
+	if _, err := s.GetSettings(ctx, teamName); err != nil {
+		return nil, err
+	}
+	if err := s.checkMembers(ctx, teamName, userIDs); err != nil {
+		return nil, err
+	}
+	return s.changeTeam(ctx, teamName, userIDs, "", reassign)
+}
+
+// MoveMember moves userID from its primary team to toTeam, which becomes the
+// primary one. With reassign the user's OPEN reviews in the old team go to its
+// remaining members, otherwise the user keeps them.
+func (s *teamService) MoveMember(ctx context.Context, userID, toTeam string, reassign bool) (*domain.MembershipReport, error) {
+	if _, err := s.GetSettings(ctx, toTeam); err != nil {
+		return nil, err
+	}
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	if u.TeamName == toTeam {
+		return &domain.MembershipReport{
+			UserIDs: []string{},
+			ReassignReport: domain.ReassignReport{
+				Moved:         []domain.ReviewMove{},
+				NotReassigned: []domain.ReviewMove{},
+			},
+		}, nil
+	}
+	return s.changeTeam(ctx, u.TeamName, []string{userID}, toTeam, reassign)
+}
+
+// RenameTeam renames a team keeping its members, settings, fallbacks and
+// code owners.
+func (s *teamService) RenameTeam(ctx context.Context, oldName, newName string) (*domain.Team, error) {
+	if _, err := s.GetSettings(ctx, oldName); err != nil {
+		return nil, err
+	}
+	if oldName == newName {
+		return s.GetSettings(ctx, newName)
+	}
+	if _, err := s.teamRepo.GetByName(ctx, newName); err == nil {
+		return nil, domain.ErrTeamExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := s.teamRepo.Rename(ctx, oldName, newName); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(ctx, newName)
+}
+
+// DeleteTeam deletes a team. A team with members is only deleted with
+// opts.Cascade, see domain.TeamDeleteOptions. Pull requests belong to the
+// author's team, so a team without members has no OPEN pull requests left.
+func (s *teamService) DeleteTeam(ctx context.Context, teamName string, opts domain.TeamDeleteOptions) (*domain.TeamDeletionReport, error) {
+	if _, err := s.GetSettings(ctx, teamName); err != nil {
+		return nil, err
+	}
+	if opts.MoveTo != "" {
+		if opts.MoveTo == teamName || !opts.Cascade {
+			return nil, domain.ErrInvalidSettings
+		}
+		if _, err := s.GetSettings(ctx, opts.MoveTo); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &domain.TeamDeletionReport{
+		TeamName:     teamName,
+		MovedMembers: []string{},
+		LeftMembers:  []string{},
+		Deactivated:  []string{},
+		ReassignReport: domain.ReassignReport{
+			Moved:         []domain.ReviewMove{},
+			NotReassigned: []domain.ReviewMove{},
+		},
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userRepo := repository.NewUserRepository(tx)
+		prRepo := repository.NewPRRepository(tx)
+
+		members, err := userRepo.GetByTeamName(ctx, teamName)
+		if err != nil {
+			return err
+		}
+		borrowed, err := prRepo.GetOpenBorrowedFrom(ctx, teamName)
+		if err != nil {
+			return err
+		}
+		if (len(members) > 0 || len(borrowed) > 0) && !opts.Cascade {
+			return domain.ErrTeamNotEmpty
+		}
+
+		var ids, only []string
+		for _, u := range members {
+			ids = append(ids, u.UserID)
+			if len(u.Teams) == 1 {
+				only = append(only, u.UserID)
+			} else {
+				report.LeftMembers = append(report.LeftMembers, u.UserID)
+			}
+		}
+
+		if opts.MoveTo != "" {
+			report.MovedMembers = append(report.MovedMembers, ids...)
+			report.LeftMembers = []string{}
+			report.MovedTo = opts.MoveTo
+			if err := userRepo.ChangeTeam(ctx, ids, teamName, opts.MoveTo); err != nil {
+				return err
+			}
+		} else {
+			// members with other teams just leave, the rest has nowhere to go
+			if err := userRepo.ChangeTeam(ctx, report.LeftMembers, teamName, ""); err != nil {
+				return err
+			}
+			if len(only) > 0 {
+				for _, id := range only {
+					if _, err := userRepo.SetIsActive(ctx, id, false); err != nil {
+						return err
+					}
+					report.Deactivated = append(report.Deactivated, id)
+				}
+
+				// the team is still there, so its fallbacks can take over the reviews
+				moved, err := s.prService.WithTx(tx).ReassignUserReviews(ctx, only)
+				if err != nil {
+					return err
+				}
+				report.ReassignReport = *moved
+
+				if err := userRepo.ChangeTeam(ctx, only, teamName, ""); err != nil {
+					return err
+				}
+			}
+		}
+
+		// reassigning the deactivated members may have changed who is borrowed
+		if borrowed, err = prRepo.GetOpenBorrowedFrom(ctx, teamName); err != nil {
+			return err
+		}
+		if err := repository.NewTeamRepository(tx).Delete(ctx, teamName); err != nil {
+			return err
+		}
+		if len(borrowed) > 0 {
+			// the reviews now belong to the author's team, which picks the replacements
+			moved, err := s.reassignBorrowed(ctx, tx, borrowed)
+			if err != nil {
+				return err
+			}
+			report.Moved = append(report.Moved, moved.Moved...)
+			report.NotReassigned = append(report.NotReassigned, moved.NotReassigned...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// reassignBorrowed hands the reviews of reviewers to other members, keeping
+// the reviewers that have no replacement.
+func (s *teamService) reassignBorrowed(ctx context.Context, tx *gorm.DB, reviewers []domain.Reviewer) (*domain.ReassignReport, error) {
+	report := &domain.ReassignReport{
+		Moved:         []domain.ReviewMove{},
+		NotReassigned: []domain.ReviewMove{},
+	}
+	prService := s.prService.WithTx(tx)
+	for _, rv := range reviewers {
+		move := domain.ReviewMove{PullRequestID: rv.PullRequestID, FromUserID: rv.UserID}
+		_, newUserID, err := prService.ReassignReviewer(ctx, rv.PullRequestID, rv.UserID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNoCandidate) || errors.Is(err, domain.ErrRoleRuleViolated) {
+				move.Reason = err.Error()
+				report.NotReassigned = append(report.NotReassigned, move)
+				continue
+			}
+			return nil, err
+		}
+		move.ToUserID = newUserID
+		report.Moved = append(report.Moved, move)
+	}
+	return report, nil
+}
+
+// checkMembers fails with ErrNotFound unless every user is a member of teamName.
+func (s *teamService) checkMembers(ctx context.Context, teamName string, userIDs []string) error {
+	users, err := s.userRepo.GetByIDs(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	members := make(map[string]bool, len(users))
+	for _, u := range users {
+		members[u.UserID] = u.InTeam(teamName)
+	}
+	for _, id := range userIDs {
+		if !members[id] {
+			return domain.ErrNotFound
+		}
+	}
+	return nil
+}
+
+// changeTeam moves userIDs from fromTeam to toTeam in one transaction and,
+// with reassign, hands their OPEN reviews to the members left in fromTeam.
+func (s *teamService) changeTeam(ctx context.Context, fromTeam string, userIDs []string, toTeam string, reassign bool) (*domain.MembershipReport, error) {
+	report := &domain.MembershipReport{
+		UserIDs: userIDs,
+		ReassignReport: domain.ReassignReport{
+			Moved:         []domain.ReviewMove{},
+			NotReassigned: []domain.ReviewMove{},
+		},
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := repository.NewUserRepository(tx).ChangeTeam(ctx, userIDs, fromTeam, toTeam); err != nil {
+			return err
+		}
+		if !reassign || fromTeam == "" {
+			return nil
+		}
+
+		moved, err := s.prService.WithTx(tx).ReassignUserReviewsFrom(ctx, userIDs, fromTeam)
+		if err != nil {
+			return err
+		}
+		report.ReassignReport = *moved
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func membersToUsers(teamName string, members []domain.TeamMember) []domain.User {
 	users := make([]domain.User, 0, len(members))
 	for _, m := range members {
 		users = append(users, domain.User{
@@ -43,14 +344,11 @@ func (s *teamService) AddTeam(ctx context.Context, teamName string, members []do
 			Username: m.Username,
 			TeamName: teamName,
 			IsActive: m.IsActive,
+			Tags:     m.Tags,
+			Role:     m.Role,
 		})
 	}
-
-	if err := s.userRepo.UpsertMany(ctx, users); err != nil {
-		return nil, nil, err
-	}
-
-	return &domain.Team{TeamName: teamName}, users, nil
+	return users
 }
 
 func (s *teamService) GetTeam(ctx context.Context, teamName string) (*domain.Team, []domain.User, error) {
@@ -64,3 +362,226 @@ func (s *teamService) GetTeam(ctx context.Context, teamName string) (*domain.Tea
 	}
 	return t, users, nil
 }
+
+func (s *teamService) GetSettings(ctx context.Context, teamName string) (*domain.Team, error) {
+	t, err := s.teamRepo.GetByName(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// UpdateSettings changes the non-nil settings of the team. It is the only
+// way team settings are written.
+func (s *teamService) UpdateSettings(ctx context.Context, teamName string, settings domain.TeamSettings) (*domain.Team, error) {
+	t, err := s.GetSettings(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.ReviewStrategy != nil {
+		if *settings.ReviewStrategy != "" && !IsKnownStrategy(*settings.ReviewStrategy) {
+			return nil, domain.ErrUnknownStrategy
+		}
+		t.ReviewStrategy = *settings.ReviewStrategy
+	}
+	if settings.DefaultMaxOpenReviews != nil {
+		t.DefaultMaxOpenReviews = *settings.DefaultMaxOpenReviews
+	}
+	if settings.MinReviewers != nil {
+		t.MinReviewers = *settings.MinReviewers
+	}
+	if settings.MaxReviewers != nil {
+		t.MaxReviewers = *settings.MaxReviewers
+	}
+	if settings.RequiredApprovals != nil {
+		t.RequiredApprovals = *settings.RequiredApprovals
+	}
+	if settings.RequiredRole != nil {
+		t.RequiredRole = *settings.RequiredRole
+	}
+	if settings.RequiredRoleCount != nil {
+		t.RequiredRoleCount = *settings.RequiredRoleCount
+	}
+	if settings.ClimbToParent != nil {
+		t.ClimbToParent = *settings.ClimbToParent
+	}
+	if settings.ParentTeam != nil {
+		if err := s.checkParent(ctx, teamName, *settings.ParentTeam); err != nil {
+			return nil, err
+		}
+		t.ParentTeam = *settings.ParentTeam
+	}
+
+	if t.DefaultMaxOpenReviews < 0 || t.MinReviewers < 0 || t.MaxReviewers < 1 || t.MinReviewers > t.MaxReviewers ||
+		t.RequiredApprovals < 0 || t.RequiredRoleCount < 0 || t.RequiredRoleCount > t.MaxReviewers ||
+		(t.RequiredRoleCount > 0 && t.RequiredRole == "") {
+		return nil, domain.ErrInvalidSettings
+	}
+
+	if err := s.teamRepo.Update(ctx, *t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ListTeams returns a page of teams ordered by name with member counts and
+// open-review load.
+func (s *teamService) ListTeams(ctx context.Context, filter domain.TeamFilter) (*domain.TeamPage, error) {
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxListLimit {
+		return nil, domain.ErrInvalidFilter
+	}
+	return s.teamRepo.List(ctx, filter)
+}
+
+// checkParent fails with ErrNotFound when parent does not exist and with
+// ErrInvalidSettings when teamName would become its own ancestor.
+func (s *teamService) checkParent(ctx context.Context, teamName, parent string) error {
+	for name := parent; name != ""; {
+		if name == teamName {
+			return domain.ErrInvalidSettings
+		}
+		t, err := s.GetSettings(ctx, name)
+		if err != nil {
+			return err
+		}
+		name = t.ParentTeam
+	}
+	return nil
+}
+
+// Tree returns top-level teams with their sub-teams, or only the subtree of
+// root when it is set.
+func (s *teamService) Tree(ctx context.Context, root string) ([]domain.TeamNode, error) {
+	if root != "" {
+		if _, err := s.GetSettings(ctx, root); err != nil {
+			return nil, err
+		}
+	}
+
+	teams, err := s.teamRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[string][]string, len(teams))
+	for _, t := range teams {
+		children[t.ParentTeam] = append(children[t.ParentTeam], t.TeamName)
+	}
+
+	var build func(name string) domain.TeamNode
+	build = func(name string) domain.TeamNode {
+		node := domain.TeamNode{TeamName: name, Children: []domain.TeamNode{}}
+		for _, child := range children[name] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	if root != "" {
+		return []domain.TeamNode{build(root)}, nil
+	}
+	res := make([]domain.TeamNode, 0, len(children[""]))
+	for _, name := range children[""] {
+		res = append(res, build(name))
+	}
+	return res, nil
+}
+
+func (s *teamService) GetFallbacks(ctx context.Context, teamName string) ([]string, error) {
+	if _, err := s.GetSettings(ctx, teamName); err != nil {
+		return nil, err
+	}
+	return s.teamRepo.GetFallbacks(ctx, teamName)
+}
+
+// SetFallbacks replaces the ordered list of teams reviewers may be borrowed
+// from when teamName cannot provide enough of its own.
+func (s *teamService) SetFallbacks(ctx context.Context, teamName string, fallbacks []string) ([]string, error) {
+	if _, err := s.GetSettings(ctx, teamName); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(fallbacks))
+	for _, name := range fallbacks {
+		if name == teamName || seen[name] {
+			return nil, domain.ErrInvalidSettings
+		}
+		seen[name] = true
+		if _, err := s.GetSettings(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.teamRepo.SetFallbacks(ctx, teamName, fallbacks); err != nil {
+		return nil, err
+	}
+	return s.teamRepo.GetFallbacks(ctx, teamName)
+}
+
+func (s *teamService) GetCodeOwners(ctx context.Context, teamName string) ([]domain.CodeOwnerRule, error) {
+	if _, err := s.GetSettings(ctx, teamName); err != nil {
+		return nil, err
+	}
+	return s.teamRepo.GetCodeOwners(ctx, teamName)
+}
+
+// SetCodeOwners replaces the code owners rules of teamName. Rules are kept in
+// the given order, a later rule overrides earlier ones for the same file.
+func (s *teamService) SetCodeOwners(ctx context.Context, teamName string, rules []domain.CodeOwnerRule) ([]domain.CodeOwnerRule, error) {
+	if _, err := s.GetSettings(ctx, teamName); err != nil {
+		return nil, err
+	}
+
+	var owners []string
+	for _, rule := range rules {
+		if strings.TrimSpace(rule.Pattern) == "" || len(rule.Owners) == 0 {
+			return nil, domain.ErrInvalidSettings
+		}
+		owners = append(owners, rule.Owners...)
+	}
+
+	users, err := s.userRepo.GetByIDs(ctx, owners)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(users))
+	for _, u := range users {
+		known[u.UserID] = true
+	}
+	for _, id := range owners {
+		if !known[id] {
+			return nil, domain.ErrNotFound
+		}
+	}
+
+	if err := s.teamRepo.SetCodeOwners(ctx, teamName, rules); err != nil {
+		return nil, err
+	}
+	return s.teamRepo.GetCodeOwners(ctx, teamName)
+}
+
+// Availability returns for every user whether they can get new reviews right
+// now: the user is active and not inside an unavailability period.
+func (s *teamService) Availability(ctx context.Context, users []domain.User) (map[string]bool, error) {
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.UserID)
+	}
+
+	away, err := s.unavailRepo.UnavailableAt(ctx, ids, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]bool, len(users))
+	for _, u := range users {
+		res[u.UserID] = u.IsActive && !away[u.UserID]
+	}
+	return res, nil
+}
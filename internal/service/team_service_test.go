@@ -3,38 +3,24 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/Detsl735/avito-test/internal/domain"
 	"github.com/Detsl735/avito-test/internal/repository"
 	"github.com/stretchr/testify/require"
-
-	"github.com/glebarez/sqlite"
-	"gorm.io/gorm"
 )
 
-func setupTeamTestDB(t *testing.T) *gorm.DB {
-	t.Helper()
-
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	require.NoError(t, err)
-
-	err = db.AutoMigrate(&domain.Team{}, &domain.User{})
-	require.NoError(t, err)
-
-	return db
-}
-
 func TestTeamService_AddTeam_Success(t *testing.T) {
-	db := setupTeamTestDB(t)
+	db := setupTestDB(t)
 
 	teamRepo := repository.NewTeamRepository(db)
 	userRepo := repository.NewUserRepository(db)
-	svc := NewTeamService(db, teamRepo, userRepo)
+	svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), nil)
 
 	ctx := context.Background()
 
 	members := []domain.TeamMember{
-		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u1", Username: "Alice", IsActive: true, Tags: []string{"go", "sql"}},
 		{UserID: "u2", Username: "Bob", IsActive: false},
 	}
 
@@ -53,17 +39,20 @@ func TestTeamService_AddTeam_Success(t *testing.T) {
 	require.NoError(t, err)
 
 	var dbUsers []domain.User
-	err = db.Where("team_name = ?", "backend").Order("user_id").Find(&dbUsers).Error
+	err = db.Where("user_id IN (?)", db.Model(&domain.TeamMembership{}).Select("user_id").Where("team_name = ?", "backend")).
+		Order("user_id").Find(&dbUsers).Error
 	require.NoError(t, err)
 	require.Len(t, dbUsers, 2)
+	require.Equal(t, []string{"go", "sql"}, dbUsers[0].Tags)
+	require.Empty(t, dbUsers[1].Tags)
 }
 
 func TestTeamService_AddTeam_TeamExists(t *testing.T) {
-	db := setupTeamTestDB(t)
+	db := setupTestDB(t)
 
 	teamRepo := repository.NewTeamRepository(db)
 	userRepo := repository.NewUserRepository(db)
-	svc := NewTeamService(db, teamRepo, userRepo)
+	svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), nil)
 
 	ctx := context.Background()
 
@@ -78,11 +67,11 @@ func TestTeamService_AddTeam_TeamExists(t *testing.T) {
 }
 
 func TestTeamService_GetTeam_Success(t *testing.T) {
-	db := setupTeamTestDB(t)
+	db := setupTestDB(t)
 
 	teamRepo := repository.NewTeamRepository(db)
 	userRepo := repository.NewUserRepository(db)
-	svc := NewTeamService(db, teamRepo, userRepo)
+	svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), nil)
 
 	err := db.Create(&domain.Team{TeamName: "backend"}).Error
 	require.NoError(t, err)
@@ -91,7 +80,7 @@ func TestTeamService_GetTeam_Success(t *testing.T) {
 		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
 		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: false},
 	}
-	err = db.Create(&users).Error
+	err = userRepo.UpsertMany(context.Background(), users)
 	require.NoError(t, err)
 
 	ctx := context.Background()
@@ -107,11 +96,11 @@ func TestTeamService_GetTeam_Success(t *testing.T) {
 }
 
 func TestTeamService_GetTeam_NotFound(t *testing.T) {
-	db := setupTeamTestDB(t)
+	db := setupTestDB(t)
 
 	teamRepo := repository.NewTeamRepository(db)
 	userRepo := repository.NewUserRepository(db)
-	svc := NewTeamService(db, teamRepo, userRepo)
+	svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), nil)
 
 	ctx := context.Background()
 
@@ -120,3 +109,459 @@ func TestTeamService_GetTeam_NotFound(t *testing.T) {
 	require.Nil(t, team)
 	require.Nil(t, users)
 }
+
+func TestTeamService_UpdateSettings(t *testing.T) {
+	db := setupTestDB(t)
+
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), nil)
+
+	ctx := context.Background()
+
+	_, _, err := svc.AddTeam(ctx, "backend", nil)
+	require.NoError(t, err)
+
+	team, err := svc.GetSettings(ctx, "backend")
+	require.NoError(t, err)
+	require.Equal(t, domain.DefaultMinReviewers, team.MinReviewers)
+	require.Equal(t, domain.DefaultMaxReviewers, team.MaxReviewers)
+
+	minR, maxR := 1, 3
+	team, err = svc.UpdateSettings(ctx, "backend", domain.TeamSettings{MinReviewers: &minR, MaxReviewers: &maxR})
+	require.NoError(t, err)
+	require.Equal(t, 1, team.MinReviewers)
+	require.Equal(t, 3, team.MaxReviewers)
+
+	tooMany := 4
+	_, err = svc.UpdateSettings(ctx, "backend", domain.TeamSettings{MinReviewers: &tooMany})
+	require.Equal(t, domain.ErrInvalidSettings, err)
+
+	roleCount := 1
+	_, err = svc.UpdateSettings(ctx, "backend", domain.TeamSettings{RequiredRoleCount: &roleCount})
+	require.Equal(t, domain.ErrInvalidSettings, err)
+
+	senior := "senior"
+	team, err = svc.UpdateSettings(ctx, "backend", domain.TeamSettings{RequiredRole: &senior, RequiredRoleCount: &roleCount})
+	require.NoError(t, err)
+	require.Equal(t, "senior", team.RequiredRole)
+	require.Equal(t, 1, team.RequiredRoleCount)
+
+	unknown := "nope"
+	_, err = svc.UpdateSettings(ctx, "backend", domain.TeamSettings{ReviewStrategy: &unknown})
+	require.Equal(t, domain.ErrUnknownStrategy, err)
+
+	_, err = svc.UpdateSettings(ctx, "no-such-team", domain.TeamSettings{})
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func TestTeamService_SetFallbacks(t *testing.T) {
+	db := setupTestDB(t)
+
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), nil)
+
+	ctx := context.Background()
+
+	for _, name := range []string{"backend", "platform", "infra"} {
+		_, _, err := svc.AddTeam(ctx, name, nil)
+		require.NoError(t, err)
+	}
+
+	got, err := svc.SetFallbacks(ctx, "backend", []string{"infra", "platform"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"infra", "platform"}, got)
+
+	got, err = svc.GetFallbacks(ctx, "backend")
+	require.NoError(t, err)
+	require.Equal(t, []string{"infra", "platform"}, got)
+
+	_, err = svc.SetFallbacks(ctx, "backend", []string{"backend"})
+	require.Equal(t, domain.ErrInvalidSettings, err)
+
+	_, err = svc.SetFallbacks(ctx, "backend", []string{"infra", "infra"})
+	require.Equal(t, domain.ErrInvalidSettings, err)
+
+	_, err = svc.SetFallbacks(ctx, "backend", []string{"no-such-team"})
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func TestTeamService_Availability(t *testing.T) {
+	db := setupTestDB(t)
+
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), nil)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	users := []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: false},
+	}
+	require.NoError(t, db.Create(&domain.Unavailability{
+		UserID:   "u2",
+		StartsAt: now.Add(-time.Hour),
+		EndsAt:   now.Add(time.Hour),
+	}).Error)
+
+	got, err := svc.Availability(ctx, users)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"u1": true, "u2": false, "u3": false}, got)
+}
+
+func TestTeamService_EditMembers(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		db := setupTestDB(t)
+
+		prSvc, userRepo := newTestPRService(t, db, strategy)
+		teamRepo := repository.NewTeamRepository(db)
+		svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), prSvc)
+
+		ctx := context.Background()
+
+		for _, name := range []string{"backend", "frontend"} {
+			require.NoError(t, db.Create(&domain.Team{TeamName: name, MinReviewers: 1, MaxReviewers: 1}).Error)
+		}
+		_, err := svc.AddMembers(ctx, "backend", []domain.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+		})
+		require.NoError(t, err)
+		_, err = svc.AddMembers(ctx, "frontend", []domain.TeamMember{
+			{UserID: "f1", Username: "Fred", IsActive: true},
+			{UserID: "u3", Username: "Charlie", IsActive: true},
+		})
+		require.NoError(t, err)
+
+		_, err = svc.AddMembers(ctx, "backend", []domain.TeamMember{{UserID: "f1", Username: "Fred", IsActive: true}})
+		require.NoError(t, err)
+		f1, err := userRepo.GetByID(ctx, "f1")
+		require.NoError(t, err)
+		require.Equal(t, "frontend", f1.TeamName)
+		require.Equal(t, []string{"frontend", "backend"}, f1.Teams)
+
+		_, err = svc.RemoveMembers(ctx, "backend", []string{"f1"}, false)
+		require.NoError(t, err)
+		f1, err = userRepo.GetByID(ctx, "f1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"frontend"}, f1.Teams)
+
+		_, err = svc.AddMembers(ctx, "no-such-team", []domain.TeamMember{{UserID: "x1", Username: "X", IsActive: true}})
+		require.Equal(t, domain.ErrNotFound, err)
+
+		report, err := svc.MoveMember(ctx, "u3", "backend", false)
+		require.NoError(t, err)
+		require.Equal(t, []string{"u3"}, report.UserIDs)
+
+		pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "u1")
+		require.NoError(t, err)
+		require.Len(t, pr.AssignedReviewers, 1)
+		reviewer := pr.AssignedReviewers[0]
+		other := map[string]string{"u2": "u3", "u3": "u2"}[reviewer]
+
+		report, err = svc.MoveMember(ctx, reviewer, "frontend", true)
+		require.NoError(t, err)
+		require.Equal(t, []domain.ReviewMove{{PullRequestID: "pr-1", FromUserID: reviewer, ToUserID: other}}, report.Moved)
+
+		u, err := userRepo.GetByID(ctx, reviewer)
+		require.NoError(t, err)
+		require.Equal(t, "frontend", u.TeamName)
+
+		_, err = svc.RemoveMembers(ctx, "backend", []string{"f1"}, false)
+		require.Equal(t, domain.ErrNotFound, err)
+
+		report, err = svc.RemoveMembers(ctx, "backend", []string{other}, false)
+		require.NoError(t, err)
+		require.Empty(t, report.Moved)
+
+		full, err := prSvc.GetPR(ctx, "pr-1")
+		require.NoError(t, err)
+		require.Equal(t, []string{other}, full.AssignedReviewers)
+
+		u, err = userRepo.GetByID(ctx, other)
+		require.NoError(t, err)
+		require.Empty(t, u.TeamName)
+	})
+}
+
+func TestTeamService_RenameAndDelete(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		db := setupTestDB(t)
+
+		prSvc, userRepo := newTestPRService(t, db, strategy)
+		teamRepo := repository.NewTeamRepository(db)
+		svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), prSvc)
+
+		ctx := context.Background()
+
+		for _, name := range []string{"backend", "frontend", "infra"} {
+			require.NoError(t, db.Create(&domain.Team{TeamName: name, MinReviewers: 1, MaxReviewers: 1}).Error)
+		}
+		require.NoError(t, teamRepo.SetFallbacks(ctx, "backend", []string{"infra"}))
+		require.NoError(t, teamRepo.SetFallbacks(ctx, "frontend", []string{"backend"}))
+		require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+			{UserID: "b1", Username: "Bob", TeamName: "backend", IsActive: true},
+			{UserID: "b2", Username: "Bill", TeamName: "backend", IsActive: true},
+			{UserID: "f1", Username: "Fred", TeamName: "frontend", IsActive: true},
+			{UserID: "i1", Username: "Ivan", TeamName: "infra", IsActive: true},
+		}))
+
+		_, err := svc.RenameTeam(ctx, "backend", "frontend")
+		require.Equal(t, domain.ErrTeamExists, err)
+		_, err = svc.RenameTeam(ctx, "no-such-team", "core")
+		require.Equal(t, domain.ErrNotFound, err)
+
+		team, err := svc.RenameTeam(ctx, "backend", "core")
+		require.NoError(t, err)
+		require.Equal(t, "core", team.TeamName)
+		require.Equal(t, 1, team.MaxReviewers)
+
+		_, users, err := svc.GetTeam(ctx, "core")
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		fallbacks, err := svc.GetFallbacks(ctx, "core")
+		require.NoError(t, err)
+		require.Equal(t, []string{"infra"}, fallbacks)
+		fallbacks, err = svc.GetFallbacks(ctx, "frontend")
+		require.NoError(t, err)
+		require.Equal(t, []string{"core"}, fallbacks)
+
+		pr, err := prSvc.CreatePR(ctx, "pr-1", "Test", "b1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"b2"}, pr.AssignedReviewers)
+
+		_, err = svc.DeleteTeam(ctx, "core", domain.TeamDeleteOptions{})
+		require.Equal(t, domain.ErrTeamNotEmpty, err)
+
+		report, err := svc.DeleteTeam(ctx, "core", domain.TeamDeleteOptions{Cascade: true})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"b1", "b2"}, report.Deactivated)
+		require.Equal(t, []domain.ReviewMove{{PullRequestID: "pr-1", FromUserID: "b2", ToUserID: "i1"}}, report.Moved)
+
+		_, err = svc.GetSettings(ctx, "core")
+		require.Equal(t, domain.ErrNotFound, err)
+		fallbacks, err = svc.GetFallbacks(ctx, "frontend")
+		require.NoError(t, err)
+		require.Empty(t, fallbacks)
+		u, err := userRepo.GetByID(ctx, "b2")
+		require.NoError(t, err)
+		require.False(t, u.IsActive)
+		require.Empty(t, u.TeamName)
+
+		report, err = svc.DeleteTeam(ctx, "frontend", domain.TeamDeleteOptions{Cascade: true, MoveTo: "infra"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"f1"}, report.MovedMembers)
+		u, err = userRepo.GetByID(ctx, "f1")
+		require.NoError(t, err)
+		require.True(t, u.IsActive)
+		require.Equal(t, "infra", u.TeamName)
+	})
+}
+
+func TestTeamService_DeleteTeamWithBorrowedReviewers(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		db := setupTestDB(t)
+
+		prSvc, userRepo := newTestPRService(t, db, strategy)
+		teamRepo := repository.NewTeamRepository(db)
+		svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), prSvc)
+
+		ctx := context.Background()
+
+		require.NoError(t, db.Create(&domain.Team{TeamName: "backend", MinReviewers: 2, MaxReviewers: 2}).Error)
+		for _, name := range []string{"platform", "frontend", "infra", "ops"} {
+			require.NoError(t, db.Create(&domain.Team{TeamName: name, MinReviewers: 1, MaxReviewers: 1}).Error)
+		}
+		require.NoError(t, db.Create(&domain.Team{TeamName: "platform/sre", ParentTeam: "platform", MinReviewers: 1, MaxReviewers: 1}).Error)
+		require.NoError(t, teamRepo.SetFallbacks(ctx, "backend", []string{"platform"}))
+		require.NoError(t, teamRepo.SetFallbacks(ctx, "frontend", []string{"infra"}))
+		require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+			{UserID: "b1", Username: "Bob", TeamName: "backend", IsActive: true},
+			{UserID: "b2", Username: "Bill", TeamName: "backend", IsActive: true},
+			{UserID: "p1", Username: "Paul", TeamName: "ops", IsActive: true},
+			{UserID: "p1", Username: "Paul", TeamName: "platform", IsActive: true},
+			{UserID: "f1", Username: "Fred", TeamName: "frontend", IsActive: true},
+			{UserID: "i1", Username: "Ivan", TeamName: "infra", IsActive: true},
+		}))
+
+		pr, err := prSvc.CreatePR(ctx, "pr-1", "Backend", "b1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"b2", "p1"}, pr.AssignedReviewers)
+		require.Equal(t, "platform", pr.Reviewers[1].FallbackTeam)
+		pr, err = prSvc.CreatePR(ctx, "pr-2", "Frontend", "f1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"i1"}, pr.AssignedReviewers)
+		require.Equal(t, "infra", pr.Reviewers[0].FallbackTeam)
+
+		// move_to only makes sense with cascade
+		_, err = svc.DeleteTeam(ctx, "platform", domain.TeamDeleteOptions{MoveTo: "ops"})
+		require.Equal(t, domain.ErrInvalidSettings, err)
+		_, err = svc.DeleteTeam(ctx, "platform", domain.TeamDeleteOptions{Cascade: true, MoveTo: "platform"})
+		require.Equal(t, domain.ErrInvalidSettings, err)
+
+		// the team has no members left, but p1 still reviews for it
+		_, err = svc.RemoveMembers(ctx, "platform", []string{"p1"}, false)
+		require.NoError(t, err)
+		_, err = svc.DeleteTeam(ctx, "platform", domain.TeamDeleteOptions{})
+		require.Equal(t, domain.ErrTeamNotEmpty, err)
+
+		require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+			{UserID: "b3", Username: "Ben", TeamName: "backend", IsActive: true},
+			{UserID: "f2", Username: "Finn", TeamName: "frontend", IsActive: true},
+		}))
+
+		report, err := svc.DeleteTeam(ctx, "platform", domain.TeamDeleteOptions{Cascade: true})
+		require.NoError(t, err)
+		require.Empty(t, report.Deactivated)
+		require.Equal(t, []domain.ReviewMove{{PullRequestID: "pr-1", FromUserID: "p1", ToUserID: "b3"}}, report.Moved)
+
+		full, err := prSvc.GetPR(ctx, "pr-1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"b2", "b3"}, full.AssignedReviewers)
+		fallbacks, err := svc.GetFallbacks(ctx, "backend")
+		require.NoError(t, err)
+		require.Empty(t, fallbacks)
+		team, err := svc.GetSettings(ctx, "platform/sre")
+		require.NoError(t, err)
+		require.Empty(t, team.ParentTeam)
+
+		// members move and reviews borrowed from the team are still reassigned
+		report, err = svc.DeleteTeam(ctx, "infra", domain.TeamDeleteOptions{Cascade: true, MoveTo: "ops"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"i1"}, report.MovedMembers)
+		require.Equal(t, []domain.ReviewMove{{PullRequestID: "pr-2", FromUserID: "i1", ToUserID: "f2"}}, report.Moved)
+
+		full, err = prSvc.GetPR(ctx, "pr-2")
+		require.NoError(t, err)
+		require.Equal(t, []string{"f2"}, full.AssignedReviewers)
+		require.Empty(t, full.Reviewers[0].FallbackTeam)
+
+		var dangling int64
+		require.NoError(t, db.Model(&domain.Reviewer{}).Where("fallback_team IN ?", []string{"platform", "infra"}).Count(&dangling).Error)
+		require.Zero(t, dangling)
+	})
+}
+
+func TestTeamService_Tree(t *testing.T) {
+	db := setupTestDB(t)
+
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), nil)
+
+	ctx := context.Background()
+
+	for _, name := range []string{"payments", "payments/backend", "payments/frontend", "infra"} {
+		require.NoError(t, db.Create(&domain.Team{TeamName: name}).Error)
+	}
+	parent := func(name string) domain.TeamSettings {
+		return domain.TeamSettings{ParentTeam: &name}
+	}
+
+	_, err := svc.UpdateSettings(ctx, "payments/backend", parent("payments"))
+	require.NoError(t, err)
+	_, err = svc.UpdateSettings(ctx, "payments/frontend", parent("payments"))
+	require.NoError(t, err)
+
+	_, err = svc.UpdateSettings(ctx, "payments", parent("payments/backend"))
+	require.Equal(t, domain.ErrInvalidSettings, err)
+	_, err = svc.UpdateSettings(ctx, "payments", parent("payments"))
+	require.Equal(t, domain.ErrInvalidSettings, err)
+	_, err = svc.UpdateSettings(ctx, "payments", parent("no-such-team"))
+	require.Equal(t, domain.ErrNotFound, err)
+
+	leaf := func(name string) domain.TeamNode {
+		return domain.TeamNode{TeamName: name, Children: []domain.TeamNode{}}
+	}
+	payments := domain.TeamNode{TeamName: "payments", Children: []domain.TeamNode{
+		leaf("payments/backend"),
+		leaf("payments/frontend"),
+	}}
+
+	tree, err := svc.Tree(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, []domain.TeamNode{leaf("infra"), payments}, tree)
+
+	tree, err = svc.Tree(ctx, "payments")
+	require.NoError(t, err)
+	require.Equal(t, []domain.TeamNode{payments}, tree)
+
+	_, err = svc.Tree(ctx, "no-such-team")
+	require.Equal(t, domain.ErrNotFound, err)
+
+	_, err = svc.RenameTeam(ctx, "payments", "billing")
+	require.NoError(t, err)
+	team, err := svc.GetSettings(ctx, "payments/backend")
+	require.NoError(t, err)
+	require.Equal(t, "billing", team.ParentTeam)
+
+	_, err = svc.DeleteTeam(ctx, "billing", domain.TeamDeleteOptions{})
+	require.NoError(t, err)
+	tree, err = svc.Tree(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, []domain.TeamNode{leaf("infra"), leaf("payments/backend"), leaf("payments/frontend")}, tree)
+}
+
+func TestTeamService_ListTeams(t *testing.T) {
+	db := setupTestDB(t)
+
+	prSvc, userRepo := newTestPRService(t, db, StrategyRandom)
+	teamRepo := repository.NewTeamRepository(db)
+	svc := NewTeamService(db, teamRepo, userRepo, repository.NewUnavailabilityRepository(db), prSvc)
+
+	ctx := context.Background()
+
+	for _, name := range []string{"backend", "backend/api", "back_office", "frontend"} {
+		require.NoError(t, db.Create(&domain.Team{TeamName: name, MinReviewers: 1, MaxReviewers: 1}).Error)
+	}
+	require.NoError(t, userRepo.UpsertMany(ctx, []domain.User{
+		{UserID: "b1", Username: "Bob", TeamName: "backend", IsActive: true},
+		{UserID: "b2", Username: "Bill", TeamName: "backend", IsActive: true},
+		{UserID: "b3", Username: "Ben", TeamName: "backend", IsActive: true},
+		{UserID: "f1", Username: "Fred", TeamName: "frontend", IsActive: true},
+		{UserID: "b2", Username: "Bill", TeamName: "frontend", IsActive: true},
+	}))
+	_, err := userRepo.SetIsActive(ctx, "b3", false)
+	require.NoError(t, err)
+
+	_, err = prSvc.CreatePR(ctx, "pr-1", "Open", "b1")
+	require.NoError(t, err)
+	_, err = prSvc.CreatePR(ctx, "pr-2", "Merged", "b1")
+	require.NoError(t, err)
+	_, err = prSvc.MergePR(ctx, "pr-2")
+	require.NoError(t, err)
+
+	page, err := svc.ListTeams(ctx, domain.TeamFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []domain.TeamSummary{
+		{TeamName: "back_office"},
+		{TeamName: "backend", Members: 3, ActiveMembers: 2, OpenReviews: 1},
+	}, page.Teams)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = svc.ListTeams(ctx, domain.TeamFilter{Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Equal(t, []domain.TeamSummary{
+		{TeamName: "backend/api"},
+		{TeamName: "frontend", Members: 2, ActiveMembers: 2, OpenReviews: 1},
+	}, page.Teams)
+	require.Empty(t, page.NextCursor)
+
+	page, err = svc.ListTeams(ctx, domain.TeamFilter{NamePrefix: "back_"})
+	require.NoError(t, err)
+	require.Len(t, page.Teams, 1)
+	require.Equal(t, "back_office", page.Teams[0].TeamName)
+
+	page, err = svc.ListTeams(ctx, domain.TeamFilter{NamePrefix: "backend"})
+	require.NoError(t, err)
+	require.Len(t, page.Teams, 2)
+
+	_, err = svc.ListTeams(ctx, domain.TeamFilter{Limit: 101})
+	require.Equal(t, domain.ErrInvalidFilter, err)
+	_, err = svc.ListTeams(ctx, domain.TeamFilter{Cursor: "%%%"})
+	require.Equal(t, domain.ErrInvalidFilter, err)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/Detsl735/avito-test/internal/domain"
 	"github.com/Detsl735/avito-test/internal/repository"
@@ -11,17 +12,32 @@ import (
 type UserService interface {
 	SetIsActive(ctx context.Context, userID string, active bool) (*domain.User, error)
 	GetByID(ctx context.Context, userID string) (*domain.User, error)
+	SetMaxOpenReviews(ctx context.Context, userID string, limit *int) (*domain.User, error)
+	GetReviewCapacity(ctx context.Context, userID string) (*domain.ReviewCapacity, error)
+	DeactivateUsers(ctx context.Context, userIDs []string, reassign bool) (*domain.DeactivationReport, error)
 }
 
 type userService struct {
-	userRepo repository.UserRepository
-	db       *gorm.DB
+	userRepo  repository.UserRepository
+	teamRepo  repository.TeamRepository
+	prRepo    repository.PRRepository
+	prService PRService
+	db        *gorm.DB
 }
 
-func NewUserService(db *gorm.DB, userRepo repository.UserRepository) UserService {
+func NewUserService(
+	db *gorm.DB,
+	userRepo repository.UserRepository,
+	teamRepo repository.TeamRepository,
+	prRepo repository.PRRepository,
+	prService PRService,
+) UserService {
 	return &userService{
-		userRepo: userRepo,
-		db:       db,
+		userRepo:  userRepo,
+		teamRepo:  teamRepo,
+		prRepo:    prRepo,
+		prService: prService,
+		db:        db,
 	}
 }
 
@@ -46,3 +62,91 @@ func (s *userService) GetByID(ctx context.Context, userID string) (*domain.User,
 	}
 	return u, nil
 }
+
+func (s *userService) SetMaxOpenReviews(ctx context.Context, userID string, limit *int) (*domain.User, error) {
+	user, err := s.userRepo.SetMaxOpenReviews(ctx, userID, limit)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *userService) GetReviewCapacity(ctx context.Context, userID string) (*domain.ReviewCapacity, error) {
+	u, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	teamDefault := 0
+	team, err := s.teamRepo.GetByName(ctx, u.TeamName)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if team != nil {
+		teamDefault = team.DefaultMaxOpenReviews
+	}
+
+	loads, err := s.prRepo.CountOpenReviews(ctx, []string{u.UserID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ReviewCapacity{
+		UserID:         u.UserID,
+		MaxOpenReviews: u.MaxOpenReviews,
+		TeamDefault:    teamDefault,
+		Effective:      u.EffectiveMaxOpenReviews(teamDefault),
+		OpenReviews:    loads[u.UserID],
+	}, nil
+}
+
+// DeactivateUsers marks userIDs inactive and, when reassign is set, moves their
+// OPEN reviews to eligible teammates. Everything happens in one transaction,
+// an unknown user id rolls back the whole batch.
+func (s *userService) DeactivateUsers(ctx context.Context, userIDs []string, reassign bool) (*domain.DeactivationReport, error) {
+	report := &domain.DeactivationReport{
+		Deactivated: []string{},
+		ReassignReport: domain.ReassignReport{
+			Moved:         []domain.ReviewMove{},
+			NotReassigned: []domain.ReviewMove{},
+		},
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userRepo := repository.NewUserRepository(tx)
+
+		seen := make(map[string]bool, len(userIDs))
+		for _, id := range userIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			if _, err := userRepo.SetIsActive(ctx, id, false); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return domain.ErrNotFound
+				}
+				return err
+			}
+			report.Deactivated = append(report.Deactivated, id)
+		}
+
+		if !reassign {
+			return nil
+		}
+
+		moved, err := s.prService.WithTx(tx).ReassignUserReviews(ctx, report.Deactivated)
+		if err != nil {
+			return err
+		}
+		report.ReassignReport = *moved
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -2,36 +2,36 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/Detsl735/avito-test/internal/domain"
 	"github.com/Detsl735/avito-test/internal/repository"
 	"github.com/stretchr/testify/require"
-
-	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 )
 
+// setupUserTestDB is setupTestDB with an empty "backend" team.
 func setupUserTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
 
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	require.NoError(t, err)
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend"}).Error)
 
-	err = db.AutoMigrate(&domain.Team{}, &domain.User{})
-	require.NoError(t, err)
+	return db
+}
 
-	err = db.Create(&domain.Team{TeamName: "backend"}).Error
-	require.NoError(t, err)
+func newTestUserService(t *testing.T, db *gorm.DB, strategy string) UserService {
+	t.Helper()
 
-	return db
+	prSvc, userRepo := newTestPRService(t, db, strategy)
+	return NewUserService(db, userRepo, repository.NewTeamRepository(db), repository.NewPRRepository(db), prSvc)
 }
 
 func TestUserService_SetIsActive_Success(t *testing.T) {
 	db := setupUserTestDB(t)
 
-	userRepo := repository.NewUserRepository(db)
-	svc := NewUserService(db, userRepo)
+	svc := newTestUserService(t, db, StrategyRandom)
 
 	ctx := context.Background()
 
@@ -41,7 +41,7 @@ func TestUserService_SetIsActive_Success(t *testing.T) {
 		TeamName: "backend",
 		IsActive: true,
 	}
-	require.NoError(t, db.Create(&u).Error)
+	require.NoError(t, repository.NewUserRepository(db).UpsertMany(context.Background(), []domain.User{u}))
 
 	updated, err := svc.SetIsActive(ctx, "u1", false)
 	require.NoError(t, err)
@@ -58,8 +58,7 @@ func TestUserService_SetIsActive_Success(t *testing.T) {
 func TestUserService_SetIsActive_NotFound(t *testing.T) {
 	db := setupUserTestDB(t)
 
-	userRepo := repository.NewUserRepository(db)
-	svc := NewUserService(db, userRepo)
+	svc := newTestUserService(t, db, StrategyRandom)
 
 	ctx := context.Background()
 
@@ -72,8 +71,7 @@ func TestUserService_SetIsActive_NotFound(t *testing.T) {
 func TestUserService_GetByID_Success(t *testing.T) {
 	db := setupUserTestDB(t)
 
-	userRepo := repository.NewUserRepository(db)
-	svc := NewUserService(db, userRepo)
+	svc := newTestUserService(t, db, StrategyRandom)
 
 	u := domain.User{
 		UserID:   "u1",
@@ -81,7 +79,7 @@ func TestUserService_GetByID_Success(t *testing.T) {
 		TeamName: "backend",
 		IsActive: true,
 	}
-	require.NoError(t, db.Create(&u).Error)
+	require.NoError(t, repository.NewUserRepository(db).UpsertMany(context.Background(), []domain.User{u}))
 
 	ctx := context.Background()
 
@@ -97,8 +95,7 @@ func TestUserService_GetByID_Success(t *testing.T) {
 func TestUserService_GetByID_NotFound(t *testing.T) {
 	db := setupUserTestDB(t)
 
-	userRepo := repository.NewUserRepository(db)
-	svc := NewUserService(db, userRepo)
+	svc := newTestUserService(t, db, StrategyRandom)
 
 	ctx := context.Background()
 
@@ -107,3 +104,110 @@ func TestUserService_GetByID_NotFound(t *testing.T) {
 	require.Nil(t, got)
 	require.Equal(t, domain.ErrNotFound, err)
 }
+
+func TestUserService_ReviewCapacity(t *testing.T) {
+	db := setupUserTestDB(t)
+
+	svc := newTestUserService(t, db, StrategyRandom)
+
+	ctx := context.Background()
+
+	require.NoError(t, db.Model(&domain.Team{}).Where("team_name = ?", "backend").
+		Update("default_max_open_reviews", 3).Error)
+	require.NoError(t, repository.NewUserRepository(db).UpsertMany(ctx, []domain.User{
+		{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+	}))
+	require.NoError(t, db.Create(&domain.PullRequest{PullRequestID: "pr-1", PullRequestName: "p", AuthorID: "u2", Status: domain.PRStatusOpen}).Error)
+	require.NoError(t, db.Create(&domain.Reviewer{PullRequestID: "pr-1", UserID: "u1"}).Error)
+
+	got, err := svc.GetReviewCapacity(ctx, "u1")
+	require.NoError(t, err)
+	require.Nil(t, got.MaxOpenReviews)
+	require.Equal(t, 3, got.TeamDefault)
+	require.Equal(t, 3, got.Effective)
+	require.Equal(t, int64(1), got.OpenReviews)
+
+	limit := 1
+	updated, err := svc.SetMaxOpenReviews(ctx, "u1", &limit)
+	require.NoError(t, err)
+	require.Equal(t, 1, *updated.MaxOpenReviews)
+
+	got, err = svc.GetReviewCapacity(ctx, "u1")
+	require.NoError(t, err)
+	require.Equal(t, 1, got.Effective)
+
+	_, err = svc.SetMaxOpenReviews(ctx, "no-such-user", nil)
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func TestUserService_DeactivateUsers_ReassignsOpenReviews(t *testing.T) {
+	forEachStrategy(t, func(t *testing.T, strategy string) {
+		db := setupUserTestDB(t)
+		svc := newTestUserService(t, db, strategy)
+
+		ctx := context.Background()
+
+		require.NoError(t, repository.NewUserRepository(db).UpsertMany(ctx, []domain.User{
+			{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+			{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+			{UserID: "u3", Username: "Charlie", TeamName: "backend", IsActive: true},
+			{UserID: "u4", Username: "Dave", TeamName: "backend", IsActive: true},
+		}))
+		require.NoError(t, db.Create(&[]domain.PullRequest{
+			{PullRequestID: "pr-1", PullRequestName: "a", AuthorID: "u1", Status: domain.PRStatusOpen},
+			{PullRequestID: "pr-2", PullRequestName: "b", AuthorID: "u4", Status: domain.PRStatusOpen},
+			{PullRequestID: "pr-3", PullRequestName: "c", AuthorID: "u1", Status: domain.PRStatusMerged},
+		}).Error)
+		require.NoError(t, db.Create(&[]domain.Reviewer{
+			{PullRequestID: "pr-1", UserID: "u2"},
+			{PullRequestID: "pr-2", UserID: "u2"},
+			{PullRequestID: "pr-2", UserID: "u1"},
+			{PullRequestID: "pr-3", UserID: "u2"},
+		}).Error)
+
+		report, err := svc.DeactivateUsers(ctx, []string{"u2", "u3"}, true)
+		require.NoError(t, err)
+		require.Equal(t, []string{"u2", "u3"}, report.Deactivated)
+		require.Len(t, report.Moved, 1)
+		require.Equal(t, domain.ReviewMove{PullRequestID: "pr-1", FromUserID: "u2", ToUserID: "u4"}, report.Moved[0])
+		require.Len(t, report.NotReassigned, 1)
+		require.Equal(t, "pr-2", report.NotReassigned[0].PullRequestID)
+
+		var merged []domain.Reviewer
+		require.NoError(t, db.Where("pull_request_id = ?", "pr-3").Find(&merged).Error)
+		require.Equal(t, "u2", merged[0].UserID)
+
+		_, err = svc.DeactivateUsers(ctx, []string{"u4", "no-such-user"}, true)
+		require.Equal(t, domain.ErrNotFound, err)
+
+		u4, err := svc.GetByID(ctx, "u4")
+		require.NoError(t, err)
+		require.True(t, u4.IsActive)
+	})
+}
+
+func TestUserService_DeactivateUsers_SpreadsLoad(t *testing.T) {
+	db := setupTestDB(t)
+	svc := newTestUserService(t, db, StrategyLeastLoaded)
+
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&domain.Team{TeamName: "backend", MinReviewers: 1, MaxReviewers: 1}).Error)
+	require.NoError(t, repository.NewUserRepository(db).UpsertMany(ctx, teamMembers("backend", 5)))
+	for i := 0; i < 6; i++ {
+		id := fmt.Sprintf("pr-%d", i)
+		require.NoError(t, db.Create(&domain.PullRequest{PullRequestID: id, PullRequestName: "a", AuthorID: "u1", Status: domain.PRStatusOpen}).Error)
+		require.NoError(t, db.Create(&domain.Reviewer{PullRequestID: id, UserID: "u2"}).Error)
+	}
+
+	report, err := svc.DeactivateUsers(ctx, []string{"u2"}, true)
+	require.NoError(t, err)
+	require.Len(t, report.Moved, 6)
+
+	// every pick inside the transaction is counted by the next one
+	loads := map[string]int{}
+	for _, move := range report.Moved {
+		loads[move.ToUserID]++
+	}
+	require.Equal(t, map[string]int{"u3": 2, "u4": 2, "u5": 2}, loads)
+}
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS team_name TEXT NOT NULL DEFAULT '';
+
+UPDATE users u
+SET team_name = m.team_name
+FROM team_memberships m
+WHERE m.user_id = u.user_id AND m.is_primary;
+
+CREATE INDEX IF NOT EXISTS idx_users_team_name ON users(team_name);
+
+DROP TABLE IF EXISTS team_memberships;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS team_memberships
+(
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    team_name TEXT NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    is_primary BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, team_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_memberships_team_name ON team_memberships(team_name);
+
+INSERT INTO team_memberships (user_id, team_name, is_primary)
+SELECT u.user_id, u.team_name, TRUE
+FROM users u
+WHERE u.team_name <> ''
+  AND NOT EXISTS (SELECT 1 FROM team_memberships m WHERE m.user_id = u.user_id);
+
+DROP INDEX IF EXISTS idx_users_team_name;
+ALTER TABLE users DROP COLUMN IF EXISTS team_name;